@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"goLangToDoApp/pkg/base"
+	"log/slog"
+	"net/http"
+)
+
+// errorResponse is the JSON body of every failed request.
+type errorResponse struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Usage   string `json:"usage,omitempty"`
+}
+
+// writeError responds with the status matching err, adding usage to bad
+// requests. Details of server side failures are only logged, not sent to the
+// client.
+func writeError(ctx context.Context, res http.ResponseWriter, err error, usage string) {
+	status, code := base.ErrorStatus(err)
+	body := errorResponse{Status: status, Code: code, Message: err.Error()}
+	if status == http.StatusBadRequest {
+		body.Usage = usage
+	}
+	if status >= http.StatusInternalServerError {
+		body.Message = http.StatusText(status)
+		slog.ErrorContext(ctx, "Request failed.", "status", status, "error", err)
+	} else {
+		slog.WarnContext(ctx, "Request rejected.", "status", status, "error", err)
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("X-Content-Type-Options", "nosniff")
+	res.WriteHeader(status)
+	encoder := json.NewEncoder(res)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(body)
+}
@@ -1,82 +1,157 @@
 package main
 
 import (
-	"context"
 	"encoding/json"
-	"errors"
+	"flag"
 	"fmt"
+	"goLangToDoApp/pkg/auth"
 	"goLangToDoApp/pkg/base"
-	todo "goLangToDoApp/pkg/todoCon"
+	"goLangToDoApp/pkg/config"
+	"goLangToDoApp/pkg/core"
+	"goLangToDoApp/pkg/webhook"
 	"log/slog"
 	"net/http"
+	"os"
 	"strconv"
+	"time"
 )
 
-var fileName string
-var store *todo.ToDoStore
+var (
+	stores     *base.UserStores
+	events     *base.Events
+	hooks      *webhook.Registry
+	dispatcher *webhook.Dispatcher
+)
 
 func main() {
 	ctx := base.Init()
-	fileName = base.DataFile
+	defaults := config.Default()
+	defaults.Store = base.StoreActor
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], defaults)
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid configuration", "error", err)
+		os.Exit(2)
+	}
+	shutdown := base.NewShutdown(time.Duration(cfg.ShutdownTimeout))
+	err = base.Configure(cfg)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to apply configuration", "error", err)
+		shutdown.Exit(ctx, 1)
+	}
 
-	var err error
-	store, err = todo.NewToDoStore(fileName)
+	// Open the default list up front so broken data fails at startup
+	stores = base.NewUserStores(cfg)
+	shutdown.OnShutdown("stores", stores.Close)
+	_, err = stores.Store(ctx, base.DefaultUser)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to load to-do data", "error", err)
-		return
+		shutdown.Exit(ctx, 1)
 	}
 
+	// Deliver the changes of every list with webhooks
+	hooks = webhook.NewRegistry(cfg.WebhooksFile)
+	dispatcher = webhook.NewDispatcher(hooks, stores.Pin)
+	err = dispatcher.Start(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to start webhooks", "error", err)
+		shutdown.Exit(ctx, 1)
+	}
+	shutdown.OnShutdown("webhooks", dispatcher.Close)
+
 	slog.InfoContext(ctx, "Welcome to Manwendra's To-Do List Application.", "method", "ToDoListApi")
 
-	// Setup Http Server endpoints
-	mux := http.NewServeMux()
-	mux.HandleFunc("POST /todo/create", createFunc)
-	mux.HandleFunc("GET /todo/get", getFunc)
-	mux.HandleFunc("PUT /todo/update", updateFunc)
-	mux.HandleFunc("DELETE /todo/delete", deleteFunc)
+	authenticator := auth.NewAuthenticator(cfg)
+	authenticator.ErrorHandler = func(res http.ResponseWriter, req *http.Request, err error) {
+		writeError(req.Context(), res, err, "")
+	}
+	if authenticator.Disabled {
+		slog.WarnContext(ctx, "Authentication is disabled, anyone reaching the server can change every list.")
+	}
+	mux := newMux(authenticator)
+
+	events = base.NewEvents(base.EventsRefresh)
 
 	// Wrapping Handlers
-	handler := createMiddleware(ctx, mux)
+	handler := base.TraceMiddleware(mux)
 
 	server := &http.Server{
-		Addr:    ":8080",
+		Addr:    cfg.APIAddr,
 		Handler: handler,
 	}
+	server.RegisterOnShutdown(events.Stop)
+
+	slog.InfoContext(ctx, "Http Server Listening", "addr", server.Addr)
+	err = shutdown.Serve(ctx, server)
+	if err != nil {
+		slog.ErrorContext(ctx, "Http Server stopped with error:", "error", err)
+		os.Exit(1)
+	}
+	slog.InfoContext(ctx, "Http Server stopped.")
+}
+
+// newMux registers the endpoints, requiring the scopes of authenticator.
+func newMux(authenticator *auth.Authenticator) *http.ServeMux {
+	read := func(next http.HandlerFunc) http.HandlerFunc { return authenticator.Require(auth.ScopeRead, next) }
+	write := func(next http.HandlerFunc) http.HandlerFunc { return authenticator.Require(auth.ScopeWrite, next) }
 
-	slog.InfoContext(ctx, "Http Server Listening on port 8080")
-	err = server.ListenAndServe()
-	if err != nil && !errors.Is(err, http.ErrServerClosed) {
-		slog.ErrorContext(ctx, "Http Server Listening error:", err)
+	// Setup Http Server endpoints
+	// Every user has their own list, addressed through the user routes or
+	// given by the token
+	mux := http.NewServeMux()
+	for _, prefix := range []string{"", "/users/{user}"} {
+		mux.HandleFunc("GET "+prefix+"/todos", read(getFunc))
+		mux.HandleFunc("POST "+prefix+"/todos", write(postTodosFunc))
+		mux.HandleFunc("GET "+prefix+"/todos/events", read(eventsFunc))
+		mux.HandleFunc("POST "+prefix+"/todos/undo", write(undoTodosFunc))
+		mux.HandleFunc("POST "+prefix+"/todos/redo", write(redoTodosFunc))
+		mux.HandleFunc("GET "+prefix+"/todos/{id}", read(getTodoFunc))
+		mux.HandleFunc("PUT "+prefix+"/todos/{id}", write(putTodoFunc))
+		mux.HandleFunc("PATCH "+prefix+"/todos/{id}", write(patchTodoFunc))
+		mux.HandleFunc("DELETE "+prefix+"/todos/{id}", write(deleteTodoFunc))
+		mux.HandleFunc("GET "+prefix+"/webhooks", read(getWebhooksFunc))
+		mux.HandleFunc("POST "+prefix+"/webhooks", write(postWebhooksFunc))
+		mux.HandleFunc("DELETE "+prefix+"/webhooks/{hook}", write(deleteWebhookFunc))
+		mux.HandleFunc("GET "+prefix+"/webhooks/{hook}/deliveries", read(getDeliveriesFunc))
 	}
 
-	base.Exit(ctx)
+	// Deprecated endpoints, kept for existing clients
+	mux.HandleFunc("POST /todo/create", deprecated("/todos", write(createFunc)))
+	mux.HandleFunc("GET /todo/get", deprecated("/todos", read(getFunc)))
+	mux.HandleFunc("PUT /todo/update", deprecated("/todos/{id}", write(updateFunc)))
+	mux.HandleFunc("DELETE /todo/delete", deprecated("/todos/{id}", write(deleteFunc)))
+	return mux
 }
 
-func createMiddleware(ctx context.Context, next http.Handler) http.Handler {
-	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-		next.ServeHTTP(res, req.WithContext(ctx))
-	})
-}
+const (
+	createUsage = `{"description": <Task Description>, "priority": <low|medium|high>, "due": <YYYY-MM-DD>, "tags": [<Tag>, ...]}`
+	updateUsage = `{"id": <Task Id>, "status": <Task Status>, "description": <Task Description>, ` +
+		`"priority": <low|medium|high>, "due": <YYYY-MM-DD|none>, "tags": [<Tag>, ...]}`
+	getUsage = "status, q, tag, due_from, due_to, sort (id|status|due|priority), order (asc|desc), limit, offset"
+)
 
 func createFunc(res http.ResponseWriter, req *http.Request) {
-	ctx := base.Init()
-	var createReq struct {
-		Description string `json:"description"`
+	ctx := req.Context()
+	store, err := requestStore(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
 	}
-	err := json.NewDecoder(req.Body).Decode(&createReq)
-	if err != nil || createReq.Description == "" {
-		msg := "Invalid request body. Accepted payload: " +
-			"\n{\n\"description\" : <Task Description>\n}"
-		http.Error(res, msg, http.StatusBadRequest)
-		slog.ErrorContext(ctx, msg)
+
+	var createReq createRequest
+	err = decodeBody(req, &createReq)
+	if err != nil {
+		writeError(ctx, res, err, createUsage)
+		return
+	}
+	fields, err := createReq.item()
+	if err != nil {
+		writeError(ctx, res, err, createUsage)
 		return
 	}
 
-	err = store.AddNewToDoItem(createReq.Description)
+	_, err = store.AddToDoItem(ctx, fields)
 	if err != nil {
-		msg := fmt.Sprintf("%s/n%s", "Failed to create new To-Do Item.", err)
-		http.Error(res, msg, http.StatusInternalServerError)
-		slog.ErrorContext(ctx, msg)
+		writeError(ctx, res, err, createUsage)
 		return
 	}
 
@@ -84,52 +159,85 @@ func createFunc(res http.ResponseWriter, req *http.Request) {
 	res.WriteHeader(http.StatusCreated)
 }
 
-func getFunc(res http.ResponseWriter, _ *http.Request) {
-	ctx := base.Init()
-	items, err := store.GetAllToDoItems()
+func getFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	store, err := requestStore(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	query, err := core.ParseQuery(req.URL.Query())
 	if err != nil {
-		msg := fmt.Sprintf("%s/n%s", "Failed to get all To-Do Items.", err)
-		http.Error(res, msg, http.StatusBadRequest)
-		slog.ErrorContext(ctx, msg)
+		writeError(ctx, res, err, getUsage)
+		return
 	}
 
+	page, err := store.QueryToDoItems(ctx, query)
+	if err != nil {
+		writeError(ctx, res, err, getUsage)
+		return
+	}
+
+	items := page.Items
+	if items == nil {
+		items = []core.Item{}
+	}
 	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextOffset > 0 {
+		res.Header().Set("X-Next-Offset", strconv.Itoa(page.NextOffset))
+	}
 	err = json.NewEncoder(res).Encode(items)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to encode To-Do Items.")
 		return
 	}
 
-	slog.InfoContext(ctx, "Fetched To-Do Item(s).")
-	slog.DebugContext(ctx, "Item(s):", items)
-	res.WriteHeader(http.StatusOK)
+	slog.InfoContext(ctx, "Fetched To-Do Item(s).", "count", len(items), "total", page.Total)
+	slog.DebugContext(ctx, "Item(s):", "items", items)
 }
 
 func updateFunc(res http.ResponseWriter, req *http.Request) {
-	ctx := base.Init()
+	ctx := req.Context()
 	var updateReq struct {
-		ItemId      int    `json:"id"`
-		Status      string `json:"status"`
-		Description string `json:"description"`
+		ItemId      int      `json:"id"`
+		Status      string   `json:"status"`
+		Description string   `json:"description"`
+		Priority    *string  `json:"priority"`
+		Due         *string  `json:"due"`
+		Tags        []string `json:"tags"`
+	}
+	err := decodeBody(req, &updateReq)
+	if err != nil {
+		writeError(ctx, res, err, updateUsage)
+		return
 	}
-	err := json.NewDecoder(req.Body).Decode(&updateReq)
-	if err != nil || (updateReq.ItemId == 0 || (updateReq.Description == "" && updateReq.Status == "")) {
-		msg := "Invalid request body. Accepted payload: \n" +
-			"{\n" +
-			"\"id\" : <Task Id>,\n" +
-			"\"status\" : <Task Status>,\n" +
-			"\"description\" : <Task Description>\n}"
 
-		http.Error(res, msg, http.StatusBadRequest)
-		slog.ErrorContext(ctx, msg)
+	patch := core.UpdatePatch(updateReq.Status, updateReq.Description)
+	patch.Priority = updateReq.Priority
+	patch.Tags = updateReq.Tags
+	if updateReq.Due != nil {
+		due, err := core.ParseDue(*updateReq.Due)
+		if err != nil {
+			writeError(ctx, res, err, updateUsage)
+			return
+		}
+		patch.Due = &due
+	}
+	if updateReq.ItemId == 0 || patch.IsEmpty() {
+		writeError(ctx, res, fmt.Errorf("%w: id and at least one field to update are required", core.ErrValidation), updateUsage)
 		return
 	}
 
-	err = store.UpdateToDoItem(updateReq.ItemId, updateReq.Status, updateReq.Description)
+	store, err := requestStore(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+	_, err = store.PatchToDoItem(ctx, updateReq.ItemId, patch)
 	if err != nil {
-		msg := "Failed to update To-Do Item."
-		http.Error(res, msg, http.StatusInternalServerError)
-		slog.ErrorContext(ctx, msg)
+		writeError(ctx, res, err, updateUsage)
 		return
 	}
 
@@ -138,28 +246,32 @@ func updateFunc(res http.ResponseWriter, req *http.Request) {
 }
 
 func deleteFunc(res http.ResponseWriter, req *http.Request) {
-	ctx := base.Init()
+	ctx := req.Context()
 	idStr := req.URL.Query().Get("id")
 	if idStr == "" {
-		msg := "Missing 'id' query parameter."
-		http.Error(res, msg, http.StatusBadRequest)
-		slog.ErrorContext(ctx, msg)
+		writeError(ctx, res, fmt.Errorf("%w: missing 'id' query parameter", core.ErrValidation), "")
 		return
 	}
 
-	id, err := strconv.Atoi(idStr)
+	store, err := requestStore(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+	items, err := store.GetAllToDoItems(ctx)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+	id, err := core.ResolveId(items, idStr)
 	if err != nil {
-		msg := "Invalid 'id' query parameter."
-		http.Error(res, msg, http.StatusBadRequest)
-		slog.ErrorContext(ctx, msg)
+		writeError(ctx, res, err, "")
 		return
 	}
 
-	err = store.DeleteToDoItem(id)
+	err = store.DeleteToDoItem(ctx, id)
 	if err != nil {
-		msg := "Failed to Delete To-Do Item."
-		http.Error(res, msg, http.StatusInternalServerError)
-		slog.ErrorContext(ctx, msg)
+		writeError(ctx, res, err, "")
 		return
 	}
 
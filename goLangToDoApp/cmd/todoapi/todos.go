@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"log/slog"
+	"net/http"
+)
+
+const (
+	putUsage = `{"description": <Task Description>, "status": <Task Status>, "priority": <low|medium|high>, ` +
+		`"due": <YYYY-MM-DD>, "tags": [<Tag>, ...]}, omitted optional fields are cleared`
+	patchUsage = `{"status": <Task Status>, "description": <Task Description>, "priority": <low|medium|high>, ` +
+		`"due": <YYYY-MM-DD|none>, "tags": [<Tag>, ...]}, omitted fields are unchanged`
+)
+
+// createRequest is the body accepted by POST /todos and POST /todo/create.
+type createRequest struct {
+	Description string   `json:"description"`
+	Priority    string   `json:"priority"`
+	Due         string   `json:"due"`
+	Tags        []string `json:"tags"`
+}
+
+// item validates the request and returns the fields of the new item.
+func (createReq createRequest) item() (core.Item, error) {
+	if createReq.Description == "" {
+		return core.Item{}, fmt.Errorf("%w: description is required", core.ErrValidation)
+	}
+	due, err := core.ParseDue(createReq.Due)
+	if err != nil {
+		return core.Item{}, err
+	}
+
+	fields := core.Item{
+		Description: createReq.Description,
+		Priority:    createReq.Priority,
+		Tags:        createReq.Tags,
+	}
+	if !due.IsZero() {
+		fields.Due = &due
+	}
+	return fields, nil
+}
+
+// patchRequest is the body accepted by PATCH /todos/{id}, where omitted
+// fields are left unchanged.
+type patchRequest struct {
+	Status      *string  `json:"status"`
+	Description *string  `json:"description"`
+	Priority    *string  `json:"priority"`
+	Due         *string  `json:"due"`
+	Tags        []string `json:"tags"`
+}
+
+func (patchReq patchRequest) patch() (core.ItemPatch, error) {
+	patch := core.ItemPatch{
+		Status:      patchReq.Status,
+		Description: patchReq.Description,
+		Priority:    patchReq.Priority,
+		Tags:        patchReq.Tags,
+	}
+	if patchReq.Description != nil && *patchReq.Description == "" {
+		return core.ItemPatch{}, fmt.Errorf("%w: description must not be empty", core.ErrValidation)
+	}
+	if patchReq.Due != nil {
+		due, err := core.ParseDue(*patchReq.Due)
+		if err != nil {
+			return core.ItemPatch{}, err
+		}
+		patch.Due = &due
+	}
+	if patch.IsEmpty() {
+		return core.ItemPatch{}, fmt.Errorf("%w: at least one field to update is required", core.ErrValidation)
+	}
+	return patch, nil
+}
+
+// putRequest is the body accepted by PUT /todos/{id}, which replaces every
+// editable field of the item.
+type putRequest struct {
+	Status      string   `json:"status"`
+	Description string   `json:"description"`
+	Priority    string   `json:"priority"`
+	Due         string   `json:"due"`
+	Tags        []string `json:"tags"`
+}
+
+func (putReq putRequest) patch() (core.ItemPatch, error) {
+	if putReq.Status == "" || putReq.Description == "" {
+		return core.ItemPatch{}, fmt.Errorf("%w: status and description are required", core.ErrValidation)
+	}
+	due, err := core.ParseDue(putReq.Due)
+	if err != nil {
+		return core.ItemPatch{}, err
+	}
+	tags := putReq.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return core.ItemPatch{
+		Status:      &putReq.Status,
+		Description: &putReq.Description,
+		Priority:    &putReq.Priority,
+		Due:         &due,
+		Tags:        tags,
+	}, nil
+}
+
+// decodeBody unmarshals the JSON request body into v.
+func decodeBody(req *http.Request, v any) error {
+	err := json.NewDecoder(req.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("%w: invalid request body: %w", core.ErrValidation, err)
+	}
+	return nil
+}
+
+// writeItem responds with item as JSON and points the Location header at it.
+func writeItem(res http.ResponseWriter, req *http.Request, status int, item core.Item) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Location", itemLocation(req, item))
+	res.WriteHeader(status)
+	err := json.NewEncoder(res).Encode(item)
+	if err != nil {
+		slog.ErrorContext(req.Context(), "Failed to encode To-Do Item.", "error", err)
+	}
+}
+
+// resolveItem returns the item addressed by the {id} path value, which is a
+// numeric id or a short id.
+func resolveItem(req *http.Request, store core.Store) (core.Item, error) {
+	items, err := store.GetAllToDoItems(req.Context())
+	if err != nil {
+		return core.Item{}, err
+	}
+	id, err := core.ResolveId(items, req.PathValue("id"))
+	if err != nil {
+		return core.Item{}, err
+	}
+	for _, item := range items {
+		if item.ItemId == id {
+			return item, nil
+		}
+	}
+	return core.Item{}, core.NotFoundError(id)
+}
+
+func postTodosFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	store, err := requestStore(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	var createReq createRequest
+	err = decodeBody(req, &createReq)
+	if err != nil {
+		writeError(ctx, res, err, createUsage)
+		return
+	}
+	fields, err := createReq.item()
+	if err != nil {
+		writeError(ctx, res, err, createUsage)
+		return
+	}
+
+	item, err := store.AddToDoItem(ctx, fields)
+	if err != nil {
+		writeError(ctx, res, err, createUsage)
+		return
+	}
+
+	slog.InfoContext(ctx, "Created new To-Do Item successfully", "Id", item.ItemId)
+	writeItem(res, req, http.StatusCreated, item)
+}
+
+func getTodoFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	store, err := requestStore(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	item, err := resolveItem(req, store)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	slog.InfoContext(ctx, "Fetched To-Do Item.", "Id", item.ItemId)
+	writeItem(res, req, http.StatusOK, item)
+}
+
+func putTodoFunc(res http.ResponseWriter, req *http.Request) {
+	var putReq putRequest
+	err := decodeBody(req, &putReq)
+	if err != nil {
+		writeError(req.Context(), res, err, putUsage)
+		return
+	}
+	patch, err := putReq.patch()
+	if err != nil {
+		writeError(req.Context(), res, err, putUsage)
+		return
+	}
+	patchTodo(res, req, patch, putUsage)
+}
+
+func patchTodoFunc(res http.ResponseWriter, req *http.Request) {
+	var patchReq patchRequest
+	err := decodeBody(req, &patchReq)
+	if err != nil {
+		writeError(req.Context(), res, err, patchUsage)
+		return
+	}
+	patch, err := patchReq.patch()
+	if err != nil {
+		writeError(req.Context(), res, err, patchUsage)
+		return
+	}
+	patchTodo(res, req, patch, patchUsage)
+}
+
+// patchTodo applies patch to the item addressed by the request path.
+func patchTodo(res http.ResponseWriter, req *http.Request, patch core.ItemPatch, usage string) {
+	ctx := req.Context()
+	store, err := requestStore(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	item, err := resolveItem(req, store)
+	if err != nil {
+		writeError(ctx, res, err, usage)
+		return
+	}
+
+	item, err = store.PatchToDoItem(ctx, item.ItemId, patch)
+	if err != nil {
+		writeError(ctx, res, err, usage)
+		return
+	}
+
+	slog.InfoContext(ctx, "Updated To-Do Item successfully.", "Id", item.ItemId)
+	writeItem(res, req, http.StatusOK, item)
+}
+
+func deleteTodoFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	store, err := requestStore(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	item, err := resolveItem(req, store)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	err = store.DeleteToDoItem(ctx, item.ItemId)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	slog.InfoContext(ctx, "Deleted To-Do Item successfully.", "Id", item.ItemId)
+	res.WriteHeader(http.StatusNoContent)
+}
+
+func undoTodosFunc(res http.ResponseWriter, req *http.Request) {
+	revertTodos(res, req, core.Store.Undo)
+}
+
+func redoTodosFunc(res http.ResponseWriter, req *http.Request) {
+	revertTodos(res, req, core.Store.Redo)
+}
+
+// revertTodos undoes or redoes the last change of the list and responds with
+// the change as it was recorded and the resulting item.
+func revertTodos(res http.ResponseWriter, req *http.Request, revert func(core.Store, context.Context) (core.Revert, error)) {
+	ctx := req.Context()
+	store, err := requestStore(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	reverted, err := revert(store, ctx)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	slog.InfoContext(ctx, "Applied To-Do journal change successfully.", "journal", reverted.Journal, "op", reverted.Change.Op, "Id", reverted.Change.Item.ItemId)
+	res.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(res).Encode(reverted)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to encode To-Do change.", "error", err)
+	}
+}
+
+// deprecated marks a legacy endpoint as deprecated in favour of successor.
+func deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Deprecation", "true")
+		res.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		next(res, req)
+	}
+}
+
+// eventsFunc streams the changes of the list as Server-Sent Events.
+func eventsFunc(res http.ResponseWriter, req *http.Request) {
+	user, err := requestUser(req)
+	if err != nil {
+		writeError(req.Context(), res, err, "")
+		return
+	}
+	// Keep the store open for as long as the stream runs
+	store, release, err := stores.Hold(req.Context(), user)
+	if err != nil {
+		writeError(req.Context(), res, err, "")
+		return
+	}
+	defer release()
+	events.Serve(res, req, store)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"goLangToDoApp/pkg/auth"
+	"goLangToDoApp/pkg/base"
+	"goLangToDoApp/pkg/config"
+	"goLangToDoApp/pkg/core"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestMux serves the endpoints on lists in a temporary directory, without
+// authentication.
+func newTestMux(t *testing.T) *http.ServeMux {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	cfg := config.Default()
+	cfg.DataFile = filepath.Join(t.TempDir(), "ToDoData.json")
+	cfg.Store = base.StoreActor
+	cfg.Auth = config.AuthDisabled
+	stores = base.NewUserStores(cfg)
+	t.Cleanup(func() {
+		if err := stores.Close(context.Background()); err != nil {
+			t.Errorf("Failed to close stores: %v", err)
+		}
+	})
+	return newMux(auth.NewAuthenticator(cfg))
+}
+
+func serve(mux *http.ServeMux, method string, target string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+	return res
+}
+
+// decodeError returns the error body of res.
+func decodeError(t *testing.T, res *httptest.ResponseRecorder) errorResponse {
+	t.Helper()
+	var body errorResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected a JSON error body, got %q: %v", res.Body.String(), err)
+	}
+	return body
+}
+
+func TestTodosLifecycle(t *testing.T) {
+	mux := newTestMux(t)
+
+	res := serve(mux, "POST", "/todos", `{"description": "Buy milk", "priority": "high"}`)
+	if res.Code != http.StatusCreated || res.Header().Get("Location") != "/todos/1" {
+		t.Fatalf("Expected 201 with Location /todos/1, got %d %q", res.Code, res.Header().Get("Location"))
+	}
+	var item core.Item
+	if err := json.Unmarshal(res.Body.Bytes(), &item); err != nil || item.Description != "Buy milk" {
+		t.Errorf("Expected the created item, got %q (%v)", res.Body.String(), err)
+	}
+
+	res = serve(mux, "PATCH", "/todos/1", `{"status": "started"}`)
+	if res.Code != http.StatusOK {
+		t.Errorf("Expected 200 on patch, got %d %s", res.Code, res.Body.String())
+	}
+	res = serve(mux, "GET", "/todos/"+item.ShortId(), "")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"status":"started"`) {
+		t.Errorf("Expected the patched item by its short id, got %d %s", res.Code, res.Body.String())
+	}
+
+	res = serve(mux, "DELETE", "/todos/1", "")
+	if res.Code != http.StatusNoContent || res.Body.Len() != 0 {
+		t.Errorf("Expected 204 without body on delete, got %d %q", res.Code, res.Body.String())
+	}
+	res = serve(mux, "GET", "/todos/1", "")
+	if body := decodeError(t, res); res.Code != http.StatusNotFound || body.Status != 404 || body.Code != "not_found" {
+		t.Errorf("Expected 404 not_found after delete, got %d %+v", res.Code, body)
+	}
+}
+
+func TestTodosErrors(t *testing.T) {
+	mux := newTestMux(t)
+	if res := serve(mux, "POST", "/todos", `{"description": "Buy milk"}`); res.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", res.Code)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+		usage  bool
+	}{
+		{"MissingItem", "GET", "/todos/99999999", "", http.StatusNotFound, "not_found", false},
+		{"DeleteMissing", "DELETE", "/todos/99999999", "", http.StatusNotFound, "not_found", false},
+		{"InvalidBody", "POST", "/todos", `{"description": `, http.StatusBadRequest, "validation_failed", true},
+		{"InvalidStatus", "PATCH", "/todos/1", `{"status": "unknown"}`, http.StatusBadRequest, "invalid_status", true},
+		{"InvalidQuery", "GET", "/todos?sort=bogus", "", http.StatusBadRequest, "validation_failed", true},
+		{"NothingToRedo", "POST", "/todos/redo", "", http.StatusConflict, "conflict", false},
+		{"InvalidUser", "GET", "/users/bad%20user/todos", "", http.StatusBadRequest, "validation_failed", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := serve(mux, tc.method, tc.target, tc.body)
+			body := decodeError(t, res)
+			if res.Code != tc.status || body.Status != tc.status || body.Code != tc.code {
+				t.Errorf("Expected %d %s, got %d %+v", tc.status, tc.code, res.Code, body)
+			}
+			if (body.Usage != "") != tc.usage {
+				t.Errorf("Expected usage %v, got %q", tc.usage, body.Usage)
+			}
+			if body.Message == "" {
+				t.Errorf("Expected an error message")
+			}
+		})
+	}
+}
+
+func TestTodosUserPrefix(t *testing.T) {
+	mux := newTestMux(t)
+
+	res := serve(mux, "POST", "/users/alice/todos", `{"description": "Alice's task"}`)
+	if res.Code != http.StatusCreated || res.Header().Get("Location") != "/users/alice/todos/1" {
+		t.Fatalf("Expected 201 with Location below the user, got %d %q", res.Code, res.Header().Get("Location"))
+	}
+	if res := serve(mux, "GET", "/users/alice/todos/1", ""); res.Code != http.StatusOK {
+		t.Errorf("Expected alice's item, got %d", res.Code)
+	}
+	if res := serve(mux, "GET", "/todos/1", ""); res.Code != http.StatusNotFound {
+		t.Errorf("Expected the default list not to hold alice's item, got %d", res.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/users/alice/todos", nil)
+	req.Header.Set(base.UserHeader, "bob")
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+	if body := decodeError(t, res); res.Code != http.StatusForbidden || body.Code != "forbidden" {
+		t.Errorf("Expected 403 when the header and path users differ, got %d %+v", res.Code, body)
+	}
+}
+
+func TestDeprecatedRoutes(t *testing.T) {
+	mux := newTestMux(t)
+
+	res := serve(mux, "POST", "/todo/create", `{"description": "Buy milk"}`)
+	if res.Code != http.StatusCreated || res.Header().Get("Deprecation") != "true" ||
+		!strings.Contains(res.Header().Get("Link"), `</todos>; rel="successor-version"`) {
+		t.Errorf("Expected 201 with deprecation headers, got %d %v", res.Code, res.Header())
+	}
+	res = serve(mux, "PUT", "/todo/update", `{"id": 1, "description": "Buy oat milk"}`)
+	if res.Code != http.StatusOK || res.Header().Get("Deprecation") != "true" {
+		t.Errorf("Expected 200 with deprecation header, got %d", res.Code)
+	}
+
+	res = serve(mux, "GET", "/todo/get", "")
+	var items []core.Item
+	if err := json.Unmarshal(res.Body.Bytes(), &items); err != nil || len(items) != 1 || items[0].Description != "Buy oat milk" {
+		t.Errorf("Expected the updated item, got %q (%v)", res.Body.String(), err)
+	}
+	if res.Header().Get("X-Total-Count") != "1" {
+		t.Errorf("Expected X-Total-Count 1, got %q", res.Header().Get("X-Total-Count"))
+	}
+
+	if res := serve(mux, "DELETE", "/todo/delete", ""); res.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without id, got %d", res.Code)
+	}
+	if res := serve(mux, "DELETE", "/todo/delete?id=1", ""); res.Code != http.StatusOK {
+		t.Errorf("Expected 200 on delete, got %d", res.Code)
+	}
+	if res := serve(mux, "GET", "/todos/1", ""); res.Code != http.StatusNotFound {
+		t.Errorf("Expected the item to be deleted, got %d", res.Code)
+	}
+}
+
+func TestTodosUndoRedo(t *testing.T) {
+	mux := newTestMux(t)
+	if res := serve(mux, "POST", "/todos", `{"description": "Buy milk"}`); res.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", res.Code)
+	}
+	if res := serve(mux, "DELETE", "/todos/1", ""); res.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", res.Code)
+	}
+
+	revert := func(target string) core.Revert {
+		t.Helper()
+		res := serve(mux, "POST", target, "")
+		var reverted core.Revert
+		if err := json.Unmarshal(res.Body.Bytes(), &reverted); res.Code != http.StatusOK || err != nil {
+			t.Fatalf("Expected 200 with the revert, got %d %q (%v)", res.Code, res.Body.String(), err)
+		}
+		return reverted
+	}
+
+	// The response holds the entry as recorded, not the change reverting it.
+	reverted := revert("/todos/undo")
+	if reverted.Journal != core.JournalUndo || reverted.Change.Op != core.OpDelete ||
+		reverted.Item == nil || reverted.Item.Description != "Buy milk" {
+		t.Errorf("Expected the undone delete and the restored item, got %+v", reverted)
+	}
+	reverted = revert("/todos/undo")
+	if reverted.Change.Op != core.OpAdd || reverted.Item != nil {
+		t.Errorf("Expected the undone add without an item, got %+v", reverted)
+	}
+	reverted = revert("/todos/redo")
+	if reverted.Journal != core.JournalRedo || reverted.Change.Op != core.OpAdd ||
+		reverted.Item == nil || reverted.Item.ItemId != 1 {
+		t.Errorf("Expected the redone add and the item, got %+v", reverted)
+	}
+}
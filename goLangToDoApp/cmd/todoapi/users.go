@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"goLangToDoApp/pkg/auth"
+	"goLangToDoApp/pkg/base"
+	"goLangToDoApp/pkg/core"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// requestUser returns the user a request is made for. Authenticated requests
+// are made for the user of their token. Otherwise the user is given by the
+// {user} path value or the user header, which must agree when both are given,
+// and requests without either use the default list.
+func requestUser(req *http.Request) (string, error) {
+	pathUser := req.PathValue("user")
+	headerUser := req.Header.Get(base.UserHeader)
+	if principal, ok := auth.PrincipalFrom(req.Context()); ok {
+		for _, user := range []string{pathUser, headerUser} {
+			if user != "" && user != principal.User {
+				return "", fmt.Errorf("%w: token cannot access the list of user %q", auth.ErrForbidden, user)
+			}
+		}
+		return principal.User, nil
+	}
+	if pathUser != "" && headerUser != "" && pathUser != headerUser {
+		return "", fmt.Errorf("%w: user %q cannot access the list of user %q", auth.ErrForbidden, headerUser, pathUser)
+	}
+	if pathUser != "" {
+		return pathUser, nil
+	}
+	return headerUser, nil
+}
+
+// requestStore returns the store holding the list of the request's user.
+func requestStore(req *http.Request) (core.Store, error) {
+	user, err := requestUser(req)
+	if err != nil {
+		return nil, err
+	}
+	return stores.Store(req.Context(), user)
+}
+
+// itemLocation returns the URL of an item resource, below the user's routes
+// when the request used them.
+func itemLocation(req *http.Request, item core.Item) string {
+	location := "/todos/" + strconv.Itoa(item.ItemId)
+	if user := req.PathValue("user"); user != "" {
+		location = "/users/" + url.PathEscape(user) + location
+	}
+	return location
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"goLangToDoApp/pkg/webhook"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const webhookUsage = `{"url": <Target URL>, "events": [<created|updated|status_changed|deleted>, ...], "secret": <Signing Secret>}, ` +
+	`no events delivers all of them, no secret generates one`
+
+// webhookRequest is the body accepted by POST /webhooks.
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+func postWebhooksFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	user, err := requestUser(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	var hookReq webhookRequest
+	err = decodeBody(req, &hookReq)
+	if err != nil {
+		writeError(ctx, res, err, webhookUsage)
+		return
+	}
+	hook, err := hooks.Register(user, hookReq.URL, hookReq.Events, hookReq.Secret, time.Now())
+	if err != nil {
+		writeError(ctx, res, err, webhookUsage)
+		return
+	}
+	err = dispatcher.Watch(ctx, user)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	slog.InfoContext(ctx, "Registered webhook.", "webhook", hook.Id, "url", hook.URL)
+	writeJSON(res, req, http.StatusCreated, hook)
+}
+
+func getWebhooksFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	user, err := requestUser(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+	list, err := hooks.List(user)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+	if list == nil {
+		list = []webhook.Hook{}
+	}
+	writeJSON(res, req, http.StatusOK, list)
+}
+
+func deleteWebhookFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	user, err := requestUser(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+	err = hooks.Remove(user, req.PathValue("hook"))
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+
+	slog.InfoContext(ctx, "Removed webhook.", "webhook", req.PathValue("hook"))
+	res.WriteHeader(http.StatusNoContent)
+}
+
+func getDeliveriesFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	user, err := requestUser(req)
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+	deliveries, err := hooks.Deliveries(user, req.PathValue("hook"))
+	if err != nil {
+		writeError(ctx, res, err, "")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []webhook.Delivery{}
+	}
+	writeJSON(res, req, http.StatusOK, deliveries)
+}
+
+// writeJSON responds with v as JSON.
+func writeJSON(res http.ResponseWriter, req *http.Request, status int, v any) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(status)
+	err := json.NewEncoder(res).Encode(v)
+	if err != nil {
+		slog.ErrorContext(req.Context(), "Failed to encode response.", "error", err)
+	}
+}
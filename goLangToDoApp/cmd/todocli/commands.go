@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"goLangToDoApp/pkg/output"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commands lists the item commands, in the order of the usage.
+var commands = []command{
+	{name: "add", args: "<description>", summary: "Add a new To-Do Item", setup: addCommand},
+	{name: "list", summary: "List To-Do Items, all of them unless filtered", setup: listCommand},
+	{name: "show", args: "<id>", summary: "Show a To-Do Item", setup: showCommand},
+	{name: "update", args: "<id>", summary: "Update a To-Do Item, only the given flags are changed", setup: updateCommand},
+	{name: "done", args: "<id>", summary: "Mark a To-Do Item as completed", setup: doneCommand},
+	{name: "rm", args: "<id>...", summary: "Delete To-Do Items", setup: rmCommand},
+	{name: "undo", summary: "Undo the last changes of the To-Do List", setup: journalCommand(core.Store.Undo)},
+	{name: "redo", summary: "Redo the last undone changes of the To-Do List", setup: journalCommand(core.Store.Redo)},
+}
+
+// itemFlags defines the item flags shared by add and update.
+func itemFlags(fs *flag.FlagSet) (priority *string, due *string, tags *string) {
+	priority = fs.String("priority", "", "Priority of the Item (low, medium or high)")
+	due = fs.String("due", "", "Due date of the Item (YYYY-MM-DD, none to clear)")
+	tags = fs.String("tags", "", "Comma separated tags of the Item")
+	return priority, due, tags
+}
+
+func addCommand(fs *flag.FlagSet) runFunc {
+	priority, due, tags := itemFlags(fs)
+	return func(ctx context.Context, store core.Store, args []string) error {
+		desc := strings.Join(args, " ")
+		if desc == "" {
+			return fmt.Errorf("%w: missing description", errUsage)
+		}
+		fields := core.Item{Description: desc, Priority: *priority, Tags: core.ParseTags(*tags)}
+		dueDate, err := core.ParseDue(*due)
+		if err != nil {
+			return err
+		}
+		if !dueDate.IsZero() {
+			fields.Due = &dueDate
+		}
+		item, err := store.AddToDoItem(ctx, fields)
+		if err != nil {
+			return err
+		}
+		printItem(item)
+		return nil
+	}
+}
+
+func listCommand(fs *flag.FlagSet) runFunc {
+	status := fs.String("status", "", "List only Items with the status")
+	search := fs.String("search", "", "List only Items whose description contains the text")
+	tags := fs.String("tags", "", "List only Items with all of the comma separated tags")
+	dueFrom := fs.String("due-from", "", "List only Items due on or after the date (YYYY-MM-DD)")
+	dueTo := fs.String("due-to", "", "List only Items due on or before the date (YYYY-MM-DD)")
+	sortBy := fs.String("sort", core.SortById, "Sort Items by id, status, due or priority")
+	order := fs.String("order", "asc", "Sort order of Items (asc or desc)")
+	limit := fs.Int("limit", 0, "Maximum number of Items to list, 0 for all")
+	offset := fs.Int("offset", 0, "Number of matching Items to skip")
+	format := fs.String("output", output.FormatTable, "Output format: "+strings.Join(output.Formats, ", "))
+	columns := fs.String("columns", "", "Comma separated columns of the output, among "+strings.Join(output.Columns, ", "))
+	tmpl := fs.String("template", "", "Go template printed for every Item with -output template, e.g. '{{.ItemId}} {{.Description}}'")
+	return func(ctx context.Context, store core.Store, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("%w: unexpected arguments %q", errUsage, args)
+		}
+		selected, err := output.ParseColumns(*columns)
+		if err != nil {
+			return err
+		}
+		printer, err := output.New(output.Options{Format: *format, Columns: selected, Template: *tmpl})
+		if err != nil {
+			return err
+		}
+		values := url.Values{
+			"status":   {*status},
+			"q":        {*search},
+			"tag":      {*tags},
+			"due_from": {*dueFrom},
+			"due_to":   {*dueTo},
+			"sort":     {*sortBy},
+			"order":    {*order},
+			"limit":    {strconv.Itoa(*limit)},
+			"offset":   {strconv.Itoa(*offset)},
+		}
+		query, err := core.ParseQuery(values)
+		if err != nil {
+			return err
+		}
+		page, err := store.QueryToDoItems(ctx, query)
+		if err != nil {
+			return err
+		}
+		if len(page.Items) == 0 && printer.Format() == output.FormatTable {
+			fmt.Println("No To-Do Items.")
+			return nil
+		}
+		err = printer.Print(os.Stdout, page.Items)
+		if err != nil {
+			return err
+		}
+		// Keep the hint off the output read by scripts.
+		if page.NextOffset > 0 {
+			fmt.Fprintf(os.Stderr, "Showing %d of %d Item(s), use -offset=%d for more.\n", len(page.Items), page.Total, page.NextOffset)
+		}
+		return nil
+	}
+}
+
+func showCommand(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, store core.Store, args []string) error {
+		items, id, err := resolveOne(ctx, store, args)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if item.ItemId == id {
+				printItem(item)
+				return nil
+			}
+		}
+		return fmt.Errorf("To-Do Item %d: %w", id, core.ErrNotFound)
+	}
+}
+
+func updateCommand(fs *flag.FlagSet) runFunc {
+	status := fs.String("status", "", "New status of the Item")
+	desc := fs.String("desc", "", "New description of the Item")
+	priority, due, tags := itemFlags(fs)
+	return func(ctx context.Context, store core.Store, args []string) error {
+		_, id, err := resolveOne(ctx, store, args)
+		if err != nil {
+			return err
+		}
+		// Only flags given on the command line are changed.
+		var patch core.ItemPatch
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "status":
+				patch.Status = status
+			case "desc":
+				patch.Description = desc
+			case "priority":
+				patch.Priority = priority
+			case "tags":
+				patch.Tags = core.ParseTags(*tags)
+			case "due":
+				var dueDate time.Time
+				dueDate, err = core.ParseDue(*due)
+				patch.Due = &dueDate
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if patch.IsEmpty() {
+			return fmt.Errorf("%w: nothing to update, give at least one of -status, -desc, -priority, -due or -tags", errUsage)
+		}
+		item, err := store.PatchToDoItem(ctx, id, patch)
+		if err != nil {
+			return err
+		}
+		printItem(item)
+		return nil
+	}
+}
+
+func doneCommand(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, store core.Store, args []string) error {
+		_, id, err := resolveOne(ctx, store, args)
+		if err != nil {
+			return err
+		}
+		status := core.CompletedStatuses[0]
+		item, err := store.PatchToDoItem(ctx, id, core.ItemPatch{Status: &status})
+		if err != nil {
+			return err
+		}
+		printItem(item)
+		return nil
+	}
+}
+
+func rmCommand(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, store core.Store, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("%w: missing To-Do Item id", errUsage)
+		}
+		items, err := store.GetAllToDoItems(ctx)
+		if err != nil {
+			return err
+		}
+		// Resolve every id first, so a typo deletes nothing.
+		ids := make([]int, 0, len(args))
+		for _, ref := range args {
+			id, err := core.ResolveId(items, ref)
+			if err != nil {
+				return err
+			}
+			if !slices.ContainsFunc(items, func(item core.Item) bool { return item.ItemId == id }) {
+				return fmt.Errorf("To-Do Item %d: %w", id, core.ErrNotFound)
+			}
+			ids = append(ids, id)
+		}
+		for _, id := range ids {
+			err = store.DeleteToDoItem(ctx, id)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Deleted To-Do Item %d.\n", id)
+		}
+		return nil
+	}
+}
+
+// journalCommand returns the setup of undo and redo, which call revert -n
+// times.
+func journalCommand(revert func(core.Store, context.Context) (core.Revert, error)) func(fs *flag.FlagSet) runFunc {
+	return func(fs *flag.FlagSet) runFunc {
+		count := fs.Int("n", 1, "Number of changes, at most "+strconv.Itoa(core.JournalSize)+" are kept")
+		return func(ctx context.Context, store core.Store, args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("%w: unexpected arguments %q", errUsage, args)
+			}
+			if *count < 1 {
+				return fmt.Errorf("%w: -n must be at least 1", errUsage)
+			}
+			for range *count {
+				reverted, err := revert(store, ctx)
+				if err != nil {
+					return err
+				}
+				summary := reverted.Summary()
+				fmt.Println(strings.ToUpper(summary[:1]) + summary[1:] + ".")
+			}
+			return nil
+		}
+	}
+}
+
+// resolveOne resolves the single item reference in args, and returns it with
+// the items it was resolved against.
+func resolveOne(ctx context.Context, store core.Store, args []string) ([]core.Item, int, error) {
+	if len(args) != 1 {
+		return nil, 0, fmt.Errorf("%w: expected one To-Do Item id, got %d arguments", errUsage, len(args))
+	}
+	items, err := store.GetAllToDoItems(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	id, err := core.ResolveId(items, args[0])
+	return items, id, err
+}
+
+func printItem(item core.Item) {
+	fmt.Printf("%d. %s\nStatus: %s\n", item.ItemId, item.Description, item.Status)
+	if item.UUID != "" {
+		fmt.Printf("Ref: %s\n", item.ShortId())
+	}
+	if item.Priority != "" {
+		fmt.Printf("Priority: %s\n", item.Priority)
+	}
+	if item.Due != nil {
+		fmt.Printf("Due: %s\n", item.Due.Format(core.DateLayout))
+	}
+	if len(item.Tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(item.Tags, ", "))
+	}
+	if item.CreatedAt != nil {
+		fmt.Printf("Created: %s\n", item.CreatedAt.Local().Format(time.DateTime))
+	}
+	if item.CompletedAt != nil {
+		fmt.Printf("Completed: %s\n", item.CompletedAt.Local().Format(time.DateTime))
+	}
+}
@@ -1,86 +1,199 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"goLangToDoApp/pkg/base"
-	"goLangToDoApp/pkg/todo"
+	"goLangToDoApp/pkg/config"
+	"goLangToDoApp/pkg/core"
+	"io"
 	"log/slog"
+	"os"
+	"strings"
 )
 
-var fileName string
+// Exit codes of todocli.
+const (
+	exitOK       = 0
+	exitFailure  = 1
+	exitUsage    = 2
+	exitNotFound = 3
+)
 
-func main() {
-	ctx := base.Init()
-	fileName = base.DataFile
+// errUsage marks errors in the command line, which exit with exitUsage.
+var errUsage = errors.New("invalid usage")
 
-	slog.InfoContext(ctx, "Welcome to Manwendra's To-Do List Application.", "method", "ToDoListCli")
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	list := flag.Bool("list", false, "List all To-Do Items")
-	add := flag.Bool("add", false, "Add a new To-Do Item to List")
-	update := flag.Bool("update", false, "Update a To-Do Item")
-	remove := flag.Bool("remove", false, "Delete a To-Do Item")
+// run executes the command line args and returns the exit code.
+func run(args []string) int {
+	base.LogOutput = os.Stderr
+	ctx := base.Init()
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return exitUsage
+	}
 
-	id := flag.Int("id", 0, "ID of Item in To-Do List")
-	status := flag.String("status", "", "Status of Item in To-Do List")
-	desc := flag.String("desc", "", "Description of Item in To-Do List")
+	name := args[0]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		return helpCommand(args[1:])
+	case "token":
+		return tokenCommand(ctx, args[1:])
+	case "-list", "-add", "-update", "-remove":
+		replacement := map[string]string{"-list": "list", "-add": "add", "-update": "update", "-remove": "rm"}[name]
+		fmt.Fprintf(os.Stderr, "todocli: the %s flag was replaced by the %s command, see \"todocli help %s\".\n",
+			name, replacement, replacement)
+		return exitUsage
+	}
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "todocli: unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		return exitUsage
+	}
 
-	flag.Parse()
+	fs, runCmd := cmd.flagSet()
+	defaults := config.Default()
+	defaults.LogLevel = "warn"
+	cfg, err := config.Load(fs, args[1:], defaults)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err == nil {
+		err = base.Configure(cfg)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "todocli:", err)
+		return exitUsage
+	}
 
-	// Load All To-Do Items from file
-	store, err := todo.NewToDoStore(fileName)
+	store, err := base.OpenStore(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "todocli: failed to open To-Do List:", err)
+		return exitFailure
+	}
+	err = runCmd(ctx, store, fs.Args())
+	closeErr := store.Close(ctx)
+	if closeErr != nil {
+		slog.ErrorContext(ctx, "Failed to close To-Do List:", "error", closeErr)
+	}
+	err = errors.Join(err, closeErr)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to get item(s) of To-Do List:", "error", err)
+		fmt.Fprintln(os.Stderr, "todocli:", err)
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "Run \"todocli help %s\" for usage.\n", cmd.name)
+		}
+		return exitCode(err)
 	}
+	return exitOK
+}
 
+// exitCode returns the exit code reporting err.
+func exitCode(err error) int {
 	switch {
-	case *list:
-		// List all To-Do Item
-		items := store.GetAllToDoItems()
-		if items == nil || len(items) == 0 {
-			slog.ErrorContext(ctx, "No To-Do Item(s) in the List.")
-		}
-	case *add:
-		// Add a new To-Do Item
-		err = store.AddNewToDoItem(*desc)
-		if err != nil {
-			slog.ErrorContext(ctx, "Failed to add item to To-Do List:", "error", err)
-		}
-	case *update && *id != 0:
-		// Update a To-Do Item
-		err = store.UpdateToDoItem(*id, *status, *desc)
-		if err != nil {
-			slog.ErrorContext(ctx, "Failed to update item to To-Do List:", "error", err)
-		}
-	case *remove && *id != 0:
-		// Delete a To-Do Item
-		err = store.DeleteToDoItem(*id)
-		if err != nil {
-			slog.ErrorContext(ctx, "Failed to remove item from To-Do List:", "error", err)
-		}
+	case errors.Is(err, core.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, errUsage), errors.Is(err, core.ErrValidation), errors.Is(err, core.ErrInvalidStatus):
+		return exitUsage
 	default:
-		fmt.Println("======================== Use following flags for various operations =======================" +
-			"\n-add -header=<name> -desc <description> to \"Add a new To-Do Item\"" +
-			"\n-update -id=<itemId> -header=<name> -desc <description> to \"Update a To-Do Item\"" +
-			"\n-remove -id=<itemId> to \"Delete a To-Do Item\"" +
-			"\n===========================================================================================")
-	}
-
-	// Print All To-Do Item(s)
-	items := store.GetAllToDoItems()
-	if items != nil && len(items) > 0 {
-		slog.DebugContext(ctx, "To-Do Item(s) list.", "To-Do Item(s)", items)
-		fmt.Println("================================== Your To-Do Task Items ==================================")
-		for index, item := range items {
-			if index != 0 {
-				fmt.Println("-------------------------------------------------------------------------------------------")
-			}
-			fmt.Printf("%d. %s\nStatus: %s\n", item.ItemId, item.Description, item.Status)
+		return exitFailure
+	}
+}
+
+// runFunc runs a command on store with the arguments left after its flags.
+type runFunc func(ctx context.Context, store core.Store, args []string) error
+
+// command is a todocli subcommand.
+type command struct {
+	name    string
+	args    string
+	summary string
+	// setup defines the flags of the command on fs and returns its run
+	// function, which reads them.
+	setup func(fs *flag.FlagSet) runFunc
+}
+
+// flagSet returns the flag set of the command and its run function. Its usage
+// lists the command flags, the config flags are listed by "help config".
+func (cmd command) flagSet() (*flag.FlagSet, runFunc) {
+	fs := flag.NewFlagSet("todocli "+cmd.name, flag.ContinueOnError)
+	runCmd := cmd.setup(fs)
+
+	// Keep the command flags apart from the config flags added by Load.
+	own := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.VisitAll(func(f *flag.Flag) {
+		own.Var(f.Value, f.Name, f.Usage)
+	})
+	fs.Usage = func() {
+		cmd.printUsage(fs.Output(), own)
+	}
+	return fs, runCmd
+}
+
+func (cmd command) printUsage(w io.Writer, own *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: %s\n\n%s\n", strings.TrimSpace("todocli "+cmd.name+" [flags] "+cmd.args), cmd.summary)
+	hasFlags := false
+	own.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintln(w, "\nFlags:")
+		own.SetOutput(w)
+		own.PrintDefaults()
+	}
+	fmt.Fprintln(w, "\nThe config flags are accepted too, see \"todocli help config\".")
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
 		}
-		fmt.Println("===========================================================================================")
-	} else {
-		slog.InfoContext(ctx, "No To-Do Item(s) in the List.")
 	}
+	return command{}, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: todocli <command> [flags] [arguments]\n\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-7s %s\n", cmd.name, firstLine(cmd.summary))
+	}
+	fmt.Fprintf(w, "  %-7s %s\n", "token", "Manage API tokens")
+	fmt.Fprintf(w, "  %-7s %s\n", "help", "Show the help of a command, or of the config flags with \"help config\"")
+	fmt.Fprintln(w, "\nFlags come before the arguments of a command."+
+		"\n\nExit codes: 0 success, 1 failure, 2 invalid usage or input, 3 To-Do Item not found.")
+}
+
+// helpCommand prints the help of the command named in args.
+func helpCommand(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+	switch args[0] {
+	case "config":
+		fmt.Println("Config flags, accepted by every command:")
+		config.PrintDefaults(os.Stdout)
+		return exitOK
+	case "token":
+		fmt.Println(tokenUsage)
+		return exitOK
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "todocli: unknown command %q\n", args[0])
+		return exitUsage
+	}
+	own := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	cmd.setup(own)
+	cmd.printUsage(os.Stdout, own)
+	return exitOK
+}
 
-	base.Exit(ctx)
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
 }
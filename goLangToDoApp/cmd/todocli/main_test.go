@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configFile, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = devNull, devNull
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		_ = devNull.Close()
+	})
+
+	// Every command works on the same list, in order.
+	files := []string{"-config", configFile, "-data", filepath.Join(dir, "ToDoData.json")}
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"NoArguments", nil, exitUsage},
+		{"UnknownCommand", []string{"frobnicate"}, exitUsage},
+		{"LegacyFlag", []string{"-list"}, exitUsage},
+		{"Help", []string{"help", "add"}, exitOK},
+		{"Add", []string{"add", "Buy milk"}, exitOK},
+		{"AddMissingDescription", []string{"add"}, exitUsage},
+		{"AddInvalidPriority", []string{"add", "-priority", "urgent", "Task"}, exitUsage},
+		{"UnknownFlag", []string{"list", "-bogus"}, exitUsage},
+		{"List", []string{"list"}, exitOK},
+		{"Show", []string{"show", "1"}, exitOK},
+		{"ShowMissing", []string{"show", "99999999"}, exitNotFound},
+		{"UpdateInvalidStatus", []string{"update", "-status", "unknown", "1"}, exitUsage},
+		{"UpdateNothing", []string{"update", "1"}, exitUsage},
+		{"RemoveMissing", []string{"rm", "1", "99999999"}, exitNotFound},
+		{"Remove", []string{"rm", "1"}, exitOK},
+		{"Undo", []string{"undo"}, exitOK},
+		{"UndoConflict", []string{"undo", "-n", "5"}, exitFailure},
+	}
+	for _, tc := range tests {
+		args := tc.args
+		if len(args) > 0 {
+			if _, ok := findCommand(args[0]); ok {
+				// The list files go after the command name, before its
+				// arguments.
+				args = append(append([]string{args[0]}, files...), args[1:]...)
+			}
+		}
+		if code := run(args); code != tc.code {
+			t.Errorf("%s: expected exit code %d, got %d", tc.name, tc.code, code)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"goLangToDoApp/pkg/auth"
+	"goLangToDoApp/pkg/base"
+	"goLangToDoApp/pkg/config"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const tokenUsage = `Usage of todocli token:
+  token mint -user <user> -scope <read|read,write> [-ttl <duration>]  Mint an API token
+  token jwt -user <user> -scope <read|read,write> -ttl <duration>    Sign a JWT with the configured secret
+  token revoke <id>                                                  Revoke an API token
+  token list                                                         List API tokens`
+
+// tokenCommand runs the token subcommand and returns the exit code.
+func tokenCommand(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, tokenUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("token "+args[0], flag.ContinueOnError)
+	user := fs.String("user", base.DefaultUser, "User whose list the token accesses, empty for the default list")
+	scope := fs.String("scope", auth.ScopeRead, "Comma separated scopes granted to the token (read, write)")
+	ttl := fs.Duration("ttl", 0, "Lifetime of the token, 0 for no expiry of API tokens")
+	cfg, err := config.Load(fs, args[1:], config.Default())
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "Invalid configuration:", err)
+		return 2
+	}
+	tokens := auth.NewTokenStore(cfg.TokensFile)
+
+	switch args[0] {
+	case "mint", "jwt":
+		if *user != base.DefaultUser {
+			err = base.ValidateUser(*user)
+		}
+		var scopes []string
+		if err == nil {
+			scopes, err = auth.ParseScopes(*scope)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+
+		if args[0] == "jwt" {
+			if cfg.JWTSecret == "" {
+				fmt.Fprintln(os.Stderr, "No JWT secret configured, set jwt_secret or TODO_JWT_SECRET.")
+				return 2
+			}
+			jwt, err := auth.SignJWT([]byte(cfg.JWTSecret), *user, scopes, *ttl, time.Now())
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 2
+			}
+			fmt.Println(jwt)
+			return 0
+		}
+
+		raw, token, err := tokens.Mint(*user, scopes, *ttl, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to mint token:", "error", err)
+			return 1
+		}
+		fmt.Printf("Minted token %s for user %q with scopes %s. It is shown only once:\n%s\n",
+			token.Id, token.User, strings.Join(token.Scopes, ","), raw)
+	case "revoke":
+		if fs.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, tokenUsage)
+			return 2
+		}
+		err = tokens.Revoke(fs.Arg(0), time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to revoke token:", "error", err)
+			return 1
+		}
+		fmt.Printf("Revoked token %s.\n", fs.Arg(0))
+	case "list":
+		list, err := tokens.List()
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to list tokens:", "error", err)
+			return 1
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tUSER\tSCOPES\tCREATED\tEXPIRES\tSTATE")
+		now := time.Now()
+		for _, token := range list {
+			expires, state := "never", "active"
+			if token.ExpiresAt != nil {
+				expires = token.ExpiresAt.Local().Format(time.DateTime)
+			}
+			switch {
+			case token.RevokedAt != nil:
+				state = "revoked"
+			case !token.Active(now):
+				state = "expired"
+			}
+			fmt.Fprintf(writer, "%s\t%q\t%s\t%s\t%s\t%s\n", token.Id, token.User, strings.Join(token.Scopes, ","),
+				token.CreatedAt.Local().Format(time.DateTime), expires, state)
+		}
+		_ = writer.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, tokenUsage)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"goLangToDoApp/pkg/cmdline"
+	"goLangToDoApp/pkg/core"
+	"goLangToDoApp/pkg/output"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// listKeys are the key=value arguments of the list command selecting items.
+var listKeys = []string{"status", "q", "tag", "due_from", "due_to", "sort", "order", "limit", "offset"}
+
+// outputKeys are the key=value arguments of the list command selecting its output.
+var outputKeys = []string{"output", "columns", "template"}
+
+// fieldKeys are the key=value arguments setting optional item fields.
+var fieldKeys = []string{"priority", "due", "tags"}
+
+// replCommand is a command of the REPL.
+type replCommand struct {
+	name string
+	spec cmdline.Spec
+	// args describes the arguments in the usage.
+	args string
+	run  func(ctx context.Context, store core.Store, args cmdline.Args) error
+}
+
+func (cmd replCommand) usage() string {
+	return strings.TrimSpace(cmd.name + " " + cmd.args)
+}
+
+// The exit and help commands are handled by the read loop.
+const (
+	exitCommand = "exit"
+	helpCommand = "help"
+)
+
+var commands = []replCommand{
+	{
+		name: "list",
+		spec: cmdline.Spec{Named: slices.Concat(listKeys, outputKeys)},
+		args: "[status=<status>] [q=<text>] [tag=<tag>] [due_from=<date>] [due_to=<date>] " +
+			"[sort=<id|status|due|priority>] [order=<asc|desc>] [limit=<n>] [offset=<n>] " +
+			"[output=<table|json|jsonl|csv|yaml|template>] [columns=<col1,col2>] [template=<go-template>]",
+		run: listItems,
+	},
+	{
+		name: "add",
+		spec: cmdline.Spec{
+			Positional: []string{"desc"},
+			Required:   1,
+			Rest:       true,
+			Named:      slices.Concat([]string{"desc"}, fieldKeys),
+		},
+		args: "<description> [priority=<low|medium|high>] [due=<YYYY-MM-DD>] [tags=<tag1,tag2>] [-- <description>]",
+		run:  addItem,
+	},
+	{
+		name: "update",
+		spec: cmdline.Spec{
+			Positional: []string{"id", "status", "desc"},
+			Required:   1,
+			Rest:       true,
+			Named:      slices.Concat([]string{"status", "desc"}, fieldKeys),
+		},
+		args: "<id> [<status> [<new_description>]] [status=<status>] [desc=<text>] " +
+			"[priority=<level|none>] [due=<YYYY-MM-DD|none>] [tags=<tag1,tag2|none>]",
+		run: updateItem,
+	},
+	{
+		name: "delete",
+		spec: cmdline.Spec{Positional: []string{"id"}, Required: 1},
+		args: "<id>",
+		run:  deleteItem,
+	},
+	{
+		name: "priority",
+		spec: cmdline.Spec{Positional: []string{"id", "priority"}, Required: 2},
+		args: "<id> <low|medium|high|none>",
+		run:  updateItem,
+	},
+	{
+		name: "due",
+		spec: cmdline.Spec{Positional: []string{"id", "due"}, Required: 2},
+		args: "<id> <YYYY-MM-DD|none>",
+		run:  updateItem,
+	},
+	{
+		name: "tags",
+		spec: cmdline.Spec{Positional: []string{"id", "tags"}, Required: 2},
+		args: "<id> <tag1,tag2|none>",
+		run:  updateItem,
+	},
+	{
+		name: "undo",
+		spec: cmdline.Spec{Positional: []string{"count"}},
+		args: "[count]",
+		run:  journalCommand(core.Store.Undo),
+	},
+	{
+		name: "redo",
+		spec: cmdline.Spec{Positional: []string{"count"}},
+		args: "[count]",
+		run:  journalCommand(core.Store.Redo),
+	},
+}
+
+func findCommand(name string) (replCommand, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return replCommand{}, false
+}
+
+// commandNames returns the names of every command, including exit and help.
+func commandNames() []string {
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+	return append(names, helpCommand, exitCommand)
+}
+
+func printHelp() {
+	fmt.Println("Usage:")
+	for _, cmd := range commands {
+		fmt.Println(cmd.usage())
+	}
+	fmt.Println(helpCommand + " [command]")
+	fmt.Println(exitCommand)
+	fmt.Printf("Quote arguments containing spaces, e.g. update 3 desc=\"new text\".\n"+
+		"Words after -- are never key=value, e.g. add -- due=friday review, or use desc=\"due=friday review\".\n"+
+		"Statuses: %s\n", strings.Join(core.Statuses, ", "))
+}
+
+func listItems(ctx context.Context, store core.Store, args cmdline.Args) error {
+	values := url.Values{}
+	for _, key := range listKeys {
+		if args.Has(key) {
+			values.Set(key, args[key])
+		}
+	}
+	query, err := core.ParseQuery(values)
+	if err != nil {
+		return err
+	}
+	columns, err := output.ParseColumns(args["columns"])
+	if err != nil {
+		return err
+	}
+	printer, err := output.New(output.Options{Format: args["output"], Columns: columns, Template: args["template"]})
+	if err != nil {
+		return err
+	}
+
+	page, err := store.QueryToDoItems(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to get item(s) of To-Do List: %w", err)
+	}
+	if len(page.Items) == 0 && printer.Format() == output.FormatTable {
+		fmt.Println("No To-Do items found.")
+		return nil
+	}
+	err = printer.Print(os.Stdout, page.Items)
+	if err != nil {
+		return fmt.Errorf("failed to print item(s) of To-Do List: %w", err)
+	}
+	if page.NextOffset > 0 {
+		fmt.Printf("Showing %d of %d item(s), use offset=%d for more.\n", len(page.Items), page.Total, page.NextOffset)
+	}
+	return nil
+}
+
+func addItem(ctx context.Context, store core.Store, args cmdline.Args) error {
+	var patch core.ItemPatch
+	err := fieldPatch(&patch, args)
+	if err != nil {
+		return err
+	}
+	fields := core.Item{Description: args["desc"], Tags: patch.Tags}
+	if patch.Priority != nil {
+		fields.Priority = *patch.Priority
+	}
+	if patch.Due != nil && !patch.Due.IsZero() {
+		fields.Due = patch.Due
+	}
+	item, err := store.AddToDoItem(ctx, fields)
+	if err != nil {
+		return fmt.Errorf("failed to add item to To-Do List: %w", err)
+	}
+	fmt.Printf("Added item %d to To-Do List.\n", item.ItemId)
+	return nil
+}
+
+// updateItem changes the fields given in args, for the update command and
+// the single field commands.
+func updateItem(ctx context.Context, store core.Store, args cmdline.Args) error {
+	id, err := resolveId(ctx, store, args["id"])
+	if err != nil {
+		return err
+	}
+	var patch core.ItemPatch
+	if args.Has("status") {
+		status := args["status"]
+		patch.Status = &status
+	}
+	if args.Has("desc") {
+		desc := args["desc"]
+		patch.Description = &desc
+	}
+	err = fieldPatch(&patch, args)
+	if err != nil {
+		return err
+	}
+	if patch.IsEmpty() {
+		return fmt.Errorf("%w: nothing to update", cmdline.ErrUsage)
+	}
+
+	_, err = store.PatchToDoItem(ctx, id, patch)
+	if err != nil {
+		return fmt.Errorf("failed to update item to To-Do List: %w", err)
+	}
+	fmt.Println("To-Do item updated.")
+	return nil
+}
+
+// fieldPatch sets the priority, due date and tags given in args on patch,
+// none clearing them.
+func fieldPatch(patch *core.ItemPatch, args cmdline.Args) error {
+	if args.Has("priority") {
+		priority := args["priority"]
+		if priority == "none" {
+			priority = ""
+		}
+		patch.Priority = &priority
+	}
+	if args.Has("due") {
+		due, err := core.ParseDue(args["due"])
+		if err != nil {
+			return err
+		}
+		patch.Due = &due
+	}
+	if args.Has("tags") {
+		tags := args["tags"]
+		if tags == "none" {
+			tags = ""
+		}
+		patch.Tags = core.ParseTags(tags)
+	}
+	return nil
+}
+
+func deleteItem(ctx context.Context, store core.Store, args cmdline.Args) error {
+	id, err := resolveId(ctx, store, args["id"])
+	if err != nil {
+		return err
+	}
+	err = store.DeleteToDoItem(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete item from To-Do List: %w", err)
+	}
+	fmt.Println("To-Do item deleted.")
+	return nil
+}
+
+// journalCommand returns the run function of undo and redo, which call revert
+// count times.
+func journalCommand(revert func(core.Store, context.Context) (core.Revert, error)) func(context.Context, core.Store, cmdline.Args) error {
+	return func(ctx context.Context, store core.Store, args cmdline.Args) error {
+		count := 1
+		if args.Has("count") {
+			var err error
+			count, err = strconv.Atoi(args["count"])
+			if err != nil || count < 1 {
+				return fmt.Errorf("%w: count must be a positive number, got %q", cmdline.ErrUsage, args["count"])
+			}
+		}
+		for range count {
+			reverted, err := revert(store, ctx)
+			if err != nil {
+				return err
+			}
+			summary := reverted.Summary()
+			fmt.Println(strings.ToUpper(summary[:1]) + summary[1:] + ".")
+		}
+		return nil
+	}
+}
+
+// resolveId accepts a numeric id or the short id of an item.
+func resolveId(ctx context.Context, store core.Store, ref string) (int, error) {
+	items, err := store.GetAllToDoItems(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return core.ResolveId(items, ref)
+}
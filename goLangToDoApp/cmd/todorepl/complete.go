@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"goLangToDoApp/pkg/cmdline"
+	"goLangToDoApp/pkg/core"
+	"goLangToDoApp/pkg/lineedit"
+	"goLangToDoApp/pkg/output"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// completer completes command names, then the positional and key=value
+// arguments of the command from its spec.
+func completer(ctx context.Context, store core.Store) lineedit.Completer {
+	return func(before string) []string {
+		words := strings.Split(before, " ")
+		word := words[len(words)-1]
+		args := slices.DeleteFunc(words[:len(words)-1], func(arg string) bool { return arg == "" })
+		if len(args) == 0 {
+			return commandNames()
+		}
+		if args[0] == helpCommand && len(args) == 1 {
+			return commandNames()
+		}
+		cmd, ok := findCommand(args[0])
+		if !ok {
+			return nil
+		}
+
+		// After EndOfNamed, every word is positional.
+		named := !slices.Contains(args, cmdline.EndOfNamed)
+		if key, _, found := strings.Cut(word, "="); named && found && slices.Contains(cmd.spec.Named, key) {
+			var candidates []string
+			for _, value := range argValues(ctx, store, key) {
+				candidates = append(candidates, key+"="+value)
+			}
+			return candidates
+		}
+		var candidates []string
+		position := 0
+		afterEnd := false
+		for _, arg := range args[1:] {
+			if !afterEnd && arg == cmdline.EndOfNamed {
+				afterEnd = true
+				continue
+			}
+			if key, _, found := strings.Cut(arg, "="); afterEnd || !found || !slices.Contains(cmd.spec.Named, key) {
+				position++
+			}
+		}
+		if position < len(cmd.spec.Positional) {
+			candidates = argValues(ctx, store, cmd.spec.Positional[position])
+		}
+		if !named {
+			return candidates
+		}
+		for _, key := range cmd.spec.Named {
+			candidates = append(candidates, key+"=")
+		}
+		return candidates
+	}
+}
+
+// argValues returns the values offered for the argument name.
+func argValues(ctx context.Context, store core.Store, name string) []string {
+	switch name {
+	case "id", "tag", "tags":
+		items, err := store.GetAllToDoItems(ctx)
+		if err != nil {
+			return nil
+		}
+		var values []string
+		for _, item := range items {
+			if name == "id" {
+				values = append(values, strconv.Itoa(item.ItemId))
+				continue
+			}
+			for _, tag := range item.Tags {
+				if !slices.Contains(values, tag) {
+					values = append(values, tag)
+				}
+			}
+		}
+		if name == "tags" {
+			values = append(values, "none")
+		}
+		return values
+	case "status":
+		return core.Statuses
+	case "priority":
+		return append(slices.Clone(core.Priorities), "none")
+	case "due":
+		return []string{"none"}
+	case "sort":
+		return core.SortKeys
+	case "order":
+		return []string{"asc", "desc"}
+	case "output":
+		return output.Formats
+	case "columns":
+		return output.Columns
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"goLangToDoApp/pkg/core"
+	"goLangToDoApp/pkg/todo"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCompleter(t *testing.T) {
+	ctx := context.Background()
+	store, err := todo.NewToDoStore(filepath.Join(t.TempDir(), "ToDoData.json"))
+	if err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	defer store.Close(ctx)
+	for _, item := range []core.Item{
+		{Description: "Buy milk", Tags: []string{"home"}},
+		{Description: "Write report", Tags: []string{"work", "home"}},
+	} {
+		if _, err := store.AddToDoItem(ctx, item); err != nil {
+			t.Fatalf("Failed to add To-Do Item: %v", err)
+		}
+	}
+
+	var updateKeys []string
+	for _, key := range slices.Concat([]string{"status", "desc"}, fieldKeys) {
+		updateKeys = append(updateKeys, key+"=")
+	}
+	tests := []struct {
+		name   string
+		before string
+		want   []string
+	}{
+		{"Empty", "", commandNames()},
+		{"CommandName", "up", commandNames()},
+		{"Help", "help ", commandNames()},
+		{"UnknownCommand", "bogus ", nil},
+		{"Ids", "delete ", []string{"1", "2"}},
+		{"IdsAndKeys", "update ", slices.Concat([]string{"1", "2"}, updateKeys)},
+		{"SecondPositional", "update 1 ", slices.Concat(core.Statuses, updateKeys)},
+		{"NamedSkipped", "update priority=high 1 ", slices.Concat(core.Statuses, updateKeys)},
+		{"NamedValue", "update 1 priority=", []string{"priority=low", "priority=medium", "priority=high", "priority=none"}},
+		{"Tag", "list tag=h", []string{"tag=home", "tag=work"}},
+		{"Tags", "tags 1 ", []string{"home", "work", "none"}},
+		{"PastPositionals", "delete 1 ", nil},
+		{"EndOfNamed", "update 1 -- ", core.Statuses},
+		{"EndOfNamedValue", "add -- priority=", nil},
+	}
+	complete := completer(ctx, store)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := complete(tc.before); !slices.Equal(got, tc.want) {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -1,119 +1,137 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"goLangToDoApp/pkg/base"
-	"goLangToDoApp/pkg/todo"
+	"goLangToDoApp/pkg/cmdline"
+	"goLangToDoApp/pkg/config"
+	"goLangToDoApp/pkg/core"
+	"goLangToDoApp/pkg/lineedit"
+	"io"
 	"os"
-	"strconv"
 	"strings"
 )
 
-var fileName string
-
-var commands = []string{"list", "add", "update", "delete", "exit"}
-
 func main() {
-	fileName = base.DataFile
+	script := flag.String("f", "", "Run the commands of a script file, - for the standard input, "+
+		"instead of reading them interactively")
+	keepGoing := flag.Bool("continue", false, "Keep running a script after a command fails instead of stopping")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], config.Default())
+	if err != nil {
+		fmt.Println("Invalid configuration:", err)
+		os.Exit(2)
+	}
+	err = base.Configure(cfg)
+	if err != nil {
+		fmt.Println("Failed to apply configuration:", err)
+		return
+	}
+
+	// Commands piped in run like a script.
+	if *script == "" && !lineedit.IsTerminal(os.Stdin) {
+		*script = "-"
+	}
+	if *script != "" {
+		os.Exit(runScript(cfg, *script, *keepGoing))
+	}
 
 	fmt.Println("Welcome to Manwendra's To-Do List Application.", "method", "ToDoListRepl")
 
 	// Load All To-Do Items from file
-	store, err := todo.NewToDoStore(fileName)
+	store, err := base.OpenStore(cfg)
 	if err != nil {
 		fmt.Println("Failed to get item(s) of To-Do List:", "error", err)
+		return
 	}
 
-	fmt.Printf("Welcome to the To-Do Read-eval-print! Enter commands (%s, %s, %s, %s, %s).\n",
-		commands[0], commands[1], commands[2], commands[3], commands[4])
-	reader := bufio.NewReader(os.Stdin)
+	fmt.Printf("Welcome to the To-Do Read-eval-print! Enter commands (%s).\n", strings.Join(commandNames(), ", "))
+	ctx := context.Background()
+	editor := lineedit.New(os.Stdin, os.Stdout)
+	editor.Complete = completer(ctx, store)
+	if editor.Terminal() {
+		editor.History, err = lineedit.LoadHistory(cfg.HistoryFile, lineedit.DefaultHistorySize)
+		if err != nil {
+			fmt.Println("Failed to load history:", err)
+		}
+	}
 
 	for {
-		fmt.Print("> ")
-		input, _ := reader.ReadString('\n')
+		input, err := editor.ReadLine("> ")
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			continue
+		}
+		if err != nil {
+			// End of input, like exit.
+			if !errors.Is(err, io.EOF) {
+				fmt.Println("Failed to read command:", err)
+			} else if !editor.Terminal() {
+				fmt.Println()
+			}
+			fmt.Println("Exiting To-Do Read-eval-print...")
+			break
+		}
 		input = strings.TrimSpace(input)
+		if editor.History != nil {
+			err = editor.History.Add(input)
+			if err != nil {
+				fmt.Println("Failed to save history:", err)
+			}
+		}
 
-		if input == commands[4] {
+		exit, err := runCommand(ctx, input, store)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+		if exit {
 			fmt.Println("Exiting To-Do Read-eval-print...")
 			break
 		}
+	}
 
-		handleCommand(input, store)
+	err = store.Close(ctx)
+	if err != nil {
+		fmt.Println("Failed to close To-Do List:", err)
 	}
 }
 
-func handleCommand(input string, store *todo.ToDoStore) {
-	parts := strings.SplitN(input, " ", 2)
-	if len(parts) < 1 {
-		fmt.Printf("Invalid command. Accepted Commands are %s.\n", commands)
-		return
+// runCommand runs the command line input and reports whether it is exit.
+// Blank lines and comments starting with # do nothing. Usage errors include
+// the usage of the command.
+func runCommand(ctx context.Context, input string, store core.Store) (bool, error) {
+	input = strings.TrimSpace(input)
+	if strings.HasPrefix(input, "#") {
+		return false, nil
+	}
+	words, err := cmdline.Split(input)
+	if err != nil || len(words) == 0 {
+		return false, err
 	}
 
-	switch parts[0] {
-	case commands[0]:
-		items := store.GetAllToDoItems()
-		if len(items) == 0 {
-			fmt.Println("No To-Do items found.")
-			return
-		}
-
-		for _, item := range items {
-			fmt.Printf("%d. %s\nStatus: %s\n", item.ItemId, item.Description, item.Status)
-		}
-	case commands[1]:
-		if len(parts) < 2 {
-			fmt.Println("Usage: add <description>")
-			return
-		}
-
-		err := store.AddNewToDoItem(parts[1])
-		if err != nil {
-			fmt.Println("Failed to add item to To-Do List:", "error", err)
-		} else {
-			fmt.Println("Added item to To-Do List.")
-		}
-	case commands[2]:
-		updateParts := strings.SplitN(input, " ", 4)
-		if len(updateParts) < 3 {
-			fmt.Println("Usage: update <id> <status> <new_description>")
-			return
-		}
-		id, err := strconv.Atoi(updateParts[1])
-		if err != nil {
-			fmt.Println("Invalid ID.")
-			return
-		}
-
-		err = store.UpdateToDoItem(id, updateParts[2], updateParts[3])
-		if err != nil {
-			fmt.Println("Failed to update item to To-Do List:", err)
-		} else {
-			fmt.Println("To-Do item updated.")
-		}
-	case commands[3]:
-		if len(parts) < 2 {
-			fmt.Println("Usage: delete <id>")
-			return
-		}
-		id, err := strconv.Atoi(parts[1])
-		if err != nil {
-			fmt.Println("Invalid ID.")
-			return
-		}
-
-		err = store.DeleteToDoItem(id)
-		if err != nil {
-			fmt.Println("Failed to delete item from To-Do List:", err)
+	switch words[0] {
+	case exitCommand:
+		return true, nil
+	case helpCommand:
+		if cmd, ok := findCommand(strings.Join(words[1:], " ")); ok {
+			fmt.Println("Usage:", cmd.usage())
 		} else {
-			fmt.Println("To-Do item deleted.")
+			printHelp()
 		}
-	default:
-		fmt.Printf("Unknown command. "+
-			"\nAccepted Commands are %s."+
-			"\nUsage: "+
-			"\nadd <description>"+
-			"\nupdate <id> <status> <new_description>"+
-			"\ndelete <id>\n", commands)
+		return false, nil
+	}
+	cmd, ok := findCommand(words[0])
+	if !ok {
+		return false, fmt.Errorf("%w: unknown command %q, accepted commands are %s",
+			cmdline.ErrUsage, words[0], strings.Join(commandNames(), ", "))
+	}
+	args, err := cmd.spec.Parse(words[1:])
+	if err == nil {
+		err = cmd.run(ctx, store, args)
+	}
+	if errors.Is(err, cmdline.ErrUsage) {
+		err = fmt.Errorf("%w\nUsage: %s", err, cmd.usage())
 	}
+	return false, err
 }
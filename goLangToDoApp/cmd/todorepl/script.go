@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"goLangToDoApp/pkg/base"
+	"goLangToDoApp/pkg/config"
+	"goLangToDoApp/pkg/core"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxScriptLine bounds the length of a script line.
+const maxScriptLine = 1024 * 1024
+
+// runScript runs the commands of the script file name, - for the standard
+// input, and returns the exit code: 0 when every command succeeded, 1
+// otherwise. Errors and the summary go to the standard error, so the output of
+// the commands can be piped on.
+func runScript(cfg config.Config, name string, keepGoing bool) int {
+	script := io.Reader(os.Stdin)
+	label := "stdin"
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to open script:", err)
+			return 1
+		}
+		defer file.Close()
+		script, label = file, name
+	}
+
+	ctx := context.Background()
+	store, err := base.OpenStore(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to get item(s) of To-Do List:", err)
+		return 1
+	}
+	failed := execScript(ctx, store, script, label, keepGoing, os.Stderr)
+	err = store.Close(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to close To-Do List:", err)
+		return 1
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// execScript runs the commands read from script, labelled label in the
+// messages written to report, until exit or the end of the script. Unless
+// keepGoing, it stops at the first failed command. It reports whether any
+// command failed.
+func execScript(ctx context.Context, store core.Store, script io.Reader, label string, keepGoing bool, report io.Writer) bool {
+	scanner := bufio.NewScanner(script)
+	scanner.Buffer(nil, maxScriptLine)
+	run, failures, line := 0, 0, 0
+	stopped := false
+	for scanner.Scan() {
+		line++
+		input := strings.TrimSpace(scanner.Text())
+		if input == "" || strings.HasPrefix(input, "#") {
+			continue
+		}
+		run++
+		exit, err := runCommand(ctx, input, store)
+		if err != nil {
+			failures++
+			fmt.Fprintf(report, "%s:%d: %s: %v\n", label, line, input, err)
+			if !keepGoing {
+				stopped = true
+				break
+			}
+		}
+		if exit {
+			break
+		}
+	}
+	err := scanner.Err()
+	if err != nil {
+		fmt.Fprintf(report, "%s:%d: failed to read script: %v\n", label, line+1, err)
+	}
+
+	summary := fmt.Sprintf("%s: %d command(s) run, %d succeeded, %d failed", label, run, run-failures, failures)
+	if stopped {
+		summary += fmt.Sprintf(", stopped at line %d", line)
+	}
+	fmt.Fprintln(report, summary+".")
+	return failures > 0 || err != nil
+}
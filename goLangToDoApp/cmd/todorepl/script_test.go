@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"goLangToDoApp/pkg/config"
+	"goLangToDoApp/pkg/core"
+	"goLangToDoApp/pkg/todo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// quiet discards the output of the commands for the rest of the test.
+func quiet(t *testing.T) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = devNull, devNull
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		_ = devNull.Close()
+	})
+}
+
+func TestExecScript(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		keepGoing bool
+		failed    bool
+		summary   string
+		items     []string
+	}{
+		{
+			name:    "Success",
+			script:  "add first\n\n# a comment\n   \nadd second\nlist\n",
+			summary: "test: 3 command(s) run, 3 succeeded, 0 failed.",
+			items:   []string{"first", "second"},
+		},
+		{
+			name:    "StopsAtFirstError",
+			script:  "add first\ndelete 99999999\nadd second\n",
+			failed:  true,
+			summary: "test: 2 command(s) run, 1 succeeded, 1 failed, stopped at line 2.",
+			items:   []string{"first"},
+		},
+		{
+			name:      "Continue",
+			script:    "add first\ndelete 99999999\nadd second\nbogus\n",
+			keepGoing: true,
+			failed:    true,
+			summary:   "test: 4 command(s) run, 2 succeeded, 2 failed.",
+			items:     []string{"first", "second"},
+		},
+		{
+			name:    "Exit",
+			script:  "add first\nexit\nadd second\n",
+			summary: "test: 2 command(s) run, 2 succeeded, 0 failed.",
+			items:   []string{"first"},
+		},
+		{
+			name: "Quoting",
+			script: "add \"Buy milk # not a comment\" tags=home\n" +
+				"add 'two  spaces' \"and 'nested' quotes\"\n" +
+				"update 2 desc=\"Call \\\"Bob\\\" back\"\n",
+			summary: "test: 3 command(s) run, 3 succeeded, 0 failed.",
+			items:   []string{"Buy milk # not a comment", `Call "Bob" back`},
+		},
+		{
+			name: "LiteralDescription",
+			script: "add priority=high -- due=friday review\n" +
+				"add desc=\"tags=a,b cleanup\" tags=home\n",
+			summary: "test: 2 command(s) run, 2 succeeded, 0 failed.",
+			items:   []string{"due=friday review", "tags=a,b cleanup"},
+		},
+		{
+			name:    "UnterminatedQuote",
+			script:  "add \"first\n",
+			failed:  true,
+			summary: "test: 1 command(s) run, 0 succeeded, 1 failed, stopped at line 1.",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			quiet(t)
+			ctx := context.Background()
+			store, err := todo.NewToDoStore(filepath.Join(t.TempDir(), "ToDoData.json"))
+			if err != nil {
+				t.Fatalf("Failed to initialize store: %v", err)
+			}
+			defer store.Close(ctx)
+
+			var report strings.Builder
+			failed := execScript(ctx, store, strings.NewReader(tc.script), "test", tc.keepGoing, &report)
+			if failed != tc.failed {
+				t.Errorf("Expected failed %v, got %v", tc.failed, failed)
+			}
+			lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+			if summary := lines[len(lines)-1]; summary != tc.summary {
+				t.Errorf("Expected summary %q, got %q", tc.summary, summary)
+			}
+
+			items, err := store.GetAllToDoItems(ctx)
+			if err != nil {
+				t.Fatalf("Failed to get To-Do Items: %v", err)
+			}
+			var descs []string
+			for _, item := range items {
+				descs = append(descs, item.Description)
+			}
+			if strings.Join(descs, "|") != strings.Join(tc.items, "|") {
+				t.Errorf("Expected items %q, got %q", tc.items, descs)
+			}
+		})
+	}
+}
+
+func TestExecScriptReportsLine(t *testing.T) {
+	quiet(t)
+	ctx := context.Background()
+	store, err := todo.NewToDoStore(filepath.Join(t.TempDir(), "ToDoData.json"))
+	if err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	defer store.Close(ctx)
+
+	var report strings.Builder
+	execScript(ctx, store, strings.NewReader("# header\n\ndelete 99999999\n"), "test", false, &report)
+	if !strings.HasPrefix(report.String(), "test:3: delete 99999999: ") || !strings.Contains(report.String(), core.ErrNotFound.Error()) {
+		t.Errorf("Expected the failure with its line number, got %q", report.String())
+	}
+}
+
+func TestRunScriptExitCode(t *testing.T) {
+	quiet(t)
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.DataFile = filepath.Join(dir, "ToDoData.json")
+
+	tests := []struct {
+		name   string
+		script string
+		code   int
+	}{
+		{"Success", "add first\nlist\n", 0},
+		{"Failure", "add first\ndelete 99999999\n", 1},
+		{"Usage", "update\n", 1},
+	}
+	for _, tc := range tests {
+		name := filepath.Join(dir, tc.name+".todo")
+		if err := os.WriteFile(name, []byte(tc.script), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if code := runScript(cfg, name, false); code != tc.code {
+			t.Errorf("%s: expected exit code %d, got %d", tc.name, tc.code, code)
+		}
+	}
+	if code := runScript(cfg, filepath.Join(dir, "missing.todo"), false); code != 1 {
+		t.Errorf("Expected exit code 1 for a missing script, got %d", code)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"goLangToDoApp/pkg/core"
+	"net/http"
+	"slices"
+)
+
+const boardPath = "/todo/board"
+
+// boardPage is the data of the board template.
+type boardPage struct {
+	page
+	Columns  []boardColumn
+	CanWrite bool
+}
+
+// boardColumn holds the items with one status, in the order of the workflow.
+type boardColumn struct {
+	Status string
+	Cards  []boardCard
+}
+
+// boardCard is an item on the board with the statuses the workflow lets it
+// move to.
+type boardCard struct {
+	core.Item
+	Moves []string
+}
+
+// boardColumns groups items by status, one column per status of the active
+// workflow. Items with a status no longer in the workflow get a column after
+// the others, so they are not hidden.
+func boardColumns(items []core.Item) []boardColumn {
+	statuses := slices.Clone(core.Statuses)
+	for _, item := range items {
+		if !slices.Contains(statuses, item.Status) {
+			statuses = append(statuses, item.Status)
+		}
+	}
+
+	columns := make([]boardColumn, len(statuses))
+	for index, status := range statuses {
+		columns[index].Status = status
+	}
+	for _, item := range items {
+		card := boardCard{Item: item}
+		for _, status := range core.Statuses {
+			if status != item.Status && core.CheckTransition(item, status) == nil {
+				card.Moves = append(card.Moves, status)
+			}
+		}
+		index := slices.Index(statuses, item.Status)
+		columns[index].Cards = append(columns[index].Cards, card)
+	}
+	return columns
+}
+
+func boardFunc(res http.ResponseWriter, req *http.Request) {
+	store, err := userStore(req)
+	var items []core.Item
+	if err == nil {
+		items, err = store.GetAllToDoItems(req.Context())
+	}
+	if err != nil {
+		serverError(res, req, "Failed to get all To-Do Items.", err)
+		return
+	}
+
+	render(res, req, http.StatusOK, "board.html", boardPage{
+		page:     newPage(res, req),
+		Columns:  boardColumns(items),
+		CanWrite: canWrite(req),
+	})
+}
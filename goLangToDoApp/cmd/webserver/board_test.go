@@ -0,0 +1,65 @@
+package main
+
+import (
+	"goLangToDoApp/pkg/core"
+	"strings"
+	"testing"
+)
+
+func TestBoardColumns(t *testing.T) {
+	workflow := core.Workflow{
+		Statuses: []string{"todo", "doing", "review", "done"},
+		Transitions: map[string][]string{
+			"todo":   {"doing"},
+			"doing":  {"review", "todo"},
+			"review": {"done", "doing"},
+			"done":   {},
+		},
+	}
+	if err := core.SetWorkflow(workflow); err != nil {
+		t.Fatalf("Failed to set workflow: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = core.SetWorkflow(core.DefaultWorkflow)
+	})
+
+	items := []core.Item{
+		{ItemId: 1, Status: "review"},
+		{ItemId: 2, Status: "todo"},
+		{ItemId: 3, Status: "archived"},
+		{ItemId: 4, Status: "review"},
+		{ItemId: 5, Status: "done"},
+	}
+	columns := boardColumns(items)
+
+	var statuses []string
+	for _, column := range columns {
+		statuses = append(statuses, column.Status)
+	}
+	if got := strings.Join(statuses, ","); got != "todo,doing,review,done,archived" {
+		t.Fatalf("Expected the workflow columns then unknown statuses, got %s", got)
+	}
+
+	cards := func(column boardColumn) string {
+		var ids []string
+		for _, card := range column.Cards {
+			ids = append(ids, string(rune('0'+card.ItemId)))
+		}
+		return strings.Join(ids, ",")
+	}
+	for index, expected := range []string{"2", "", "1,4", "5", "3"} {
+		if got := cards(columns[index]); got != expected {
+			t.Errorf("Expected items %q in column %s, got %q", expected, columns[index].Status, got)
+		}
+	}
+
+	moves := map[int]string{2: "doing", 1: "doing,done", 5: "", 3: "todo,doing,review,done"}
+	for _, column := range columns {
+		for _, card := range column.Cards {
+			expected, ok := moves[card.ItemId]
+			if ok && strings.Join(card.Moves, ",") != expected {
+				t.Errorf("Expected item %d to move to %q, got %q", card.ItemId, expected, card.Moves)
+			}
+		}
+	}
+}
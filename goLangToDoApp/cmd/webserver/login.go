@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"goLangToDoApp/pkg/auth"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// loginPage is the data of the login template.
+type loginPage struct {
+	page
+	Next string
+}
+
+// authErrorFunc sends requests without valid credentials to the login page.
+func authErrorFunc(res http.ResponseWriter, req *http.Request, err error) {
+	slog.InfoContext(req.Context(), "Request rejected.", "error", err)
+	if errors.Is(err, auth.ErrForbidden) {
+		http.Error(res, "Your token does not allow this page.", http.StatusForbidden)
+		return
+	}
+	http.Redirect(res, req, "/login?next="+url.QueryEscape(req.URL.RequestURI()), http.StatusSeeOther)
+}
+
+func loginFormFunc(res http.ResponseWriter, req *http.Request) {
+	renderLogin(res, req, http.StatusOK, localPath(req.URL.Query().Get("next")), "")
+}
+
+// loginFunc verifies the submitted token and keeps it in an HTTP-only cookie.
+func loginFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	next := localPath(req.PostFormValue("next"))
+	token := strings.TrimSpace(req.PostFormValue("token"))
+	principal, err := authenticator.Verify(token)
+	if err != nil {
+		slog.InfoContext(ctx, "Sign in rejected.", "error", err)
+		renderLogin(res, req, http.StatusUnauthorized, next, "Invalid, expired or revoked token.")
+		return
+	}
+
+	http.SetCookie(res, &http.Cookie{
+		Name:     auth.CookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	slog.InfoContext(ctx, "Signed in.", "user", principal.User, "token", principal.TokenId)
+	setFlash(res, req, flashSuccess, "Signed in.")
+	http.Redirect(res, req, next, http.StatusSeeOther)
+}
+
+func logoutFunc(res http.ResponseWriter, req *http.Request) {
+	http.SetCookie(res, &http.Cookie{
+		Name:     auth.CookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	setFlash(res, req, flashSuccess, "Signed out.")
+	http.Redirect(res, req, "/login", http.StatusSeeOther)
+}
+
+func renderLogin(res http.ResponseWriter, req *http.Request, status int, next string, message string) {
+	data := loginPage{page: newPage(res, req), Next: next}
+	data.Error = message
+	render(res, req, status, "login.html", data)
+}
+
+// localPath returns next when it is a path on this server, which keeps the
+// redirect after signing in from leaving the site.
+func localPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return listPath
+	}
+	return next
+}
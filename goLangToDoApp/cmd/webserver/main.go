@@ -2,13 +2,14 @@ package main
 
 import (
 	"embed"
-	"errors"
-	"fmt"
+	"flag"
+	"goLangToDoApp/pkg/auth"
 	"goLangToDoApp/pkg/base"
-	"goLangToDoApp/pkg/todo"
-	"html/template"
+	"goLangToDoApp/pkg/config"
 	"log/slog"
 	"net/http"
+	"os"
+	"time"
 )
 
 var (
@@ -16,54 +17,92 @@ var (
 	static embed.FS
 )
 
-var fileName string
-var store *todo.ToDoStore
+var (
+	stores        *base.UserStores
+	authenticator *auth.Authenticator
+	templates     *templateSet
+	events        *base.Events
+)
 
 func main() {
 	ctx := base.Init()
-	fileName = base.DataFile
+	defaults := config.Default()
+	defaults.Store = base.StoreActor
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], defaults)
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid configuration", "error", err)
+		os.Exit(2)
+	}
+	shutdown := base.NewShutdown(time.Duration(cfg.ShutdownTimeout))
+	err = base.Configure(cfg)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to apply configuration", "error", err)
+		shutdown.Exit(ctx, 1)
+	}
 
-	var err error
-	store, err = todo.NewToDoStore(fileName)
+	// Open the default list up front so broken data fails at startup
+	stores = base.NewUserStores(cfg)
+	shutdown.OnShutdown("stores", stores.Close)
+	_, err = stores.Store(ctx, base.DefaultUser)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to load to-do data", "error", err)
-		return
+		shutdown.Exit(ctx, 1)
+	}
+
+	templates, err = newTemplateSet(cfg.TemplateDir)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse templates", "error", err)
+		shutdown.Exit(ctx, 1)
+	}
+	if cfg.TemplateDir != "" {
+		slog.InfoContext(ctx, "Reloading templates from disk on every request.", "dir", cfg.TemplateDir)
 	}
 
 	slog.InfoContext(ctx, "Welcome to Manwendra's To-Do List Application.", "method", "ToDoListWeb")
 
-	// Setup Http Server endpoints
-	mux := http.NewServeMux()
-	mux.HandleFunc("GET /todo/list", listFunc)
+	authenticator = auth.NewAuthenticator(cfg)
+	authenticator.ErrorHandler = authErrorFunc
 
-	// Serve static files for the /about endpoint
-	mux.Handle("/static/", http.FileServer(http.FS(static)))
+	mux := newMux()
 
+	events = base.NewEvents(base.EventsRefresh)
 	server := &http.Server{
-		Addr:    ":8081",
-		Handler: mux,
+		Addr:    cfg.WebAddr,
+		Handler: base.TraceMiddleware(mux),
 	}
+	server.RegisterOnShutdown(events.Stop)
 
-	slog.InfoContext(ctx, "Http Server Listening on port 8081")
-	err = server.ListenAndServe()
-	if err != nil && !errors.Is(err, http.ErrServerClosed) {
-		msg := fmt.Sprintf("%s/n %s", "Http Server Listening error.", err)
-		slog.ErrorContext(ctx, msg)
+	slog.InfoContext(ctx, "Http Server Listening", "addr", server.Addr)
+	err = shutdown.Serve(ctx, server)
+	if err != nil {
+		slog.ErrorContext(ctx, "Http Server stopped with error:", "error", err)
+		os.Exit(1)
 	}
-
-	base.Exit(ctx)
+	slog.InfoContext(ctx, "Http Server stopped.")
 }
 
-func listFunc(res http.ResponseWriter, _ *http.Request) {
-	ctx := base.Init()
-	tmpl, err := template.ParseFiles("dynamic/list.html")
-	if err != nil {
-		msg := "Failed to load template."
-		http.Error(res, msg, http.StatusInternalServerError)
-		slog.ErrorContext(ctx, msg)
-		return
-	}
+// newMux registers the pages and form endpoints, requiring the scopes of the
+// authenticator.
+func newMux() *http.ServeMux {
+	read := func(next http.HandlerFunc) http.HandlerFunc { return authenticator.Require(auth.ScopeRead, next) }
+	write := func(next http.HandlerFunc) http.HandlerFunc { return authenticator.Require(auth.ScopeWrite, next) }
 
-	items := store.GetAllToDoItems()
-	_ = tmpl.Execute(res, items)
+	// Setup Http Server endpoints
+	// Forms post to the item routes, which redirect back to the list
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+listPath, read(listFunc))
+	mux.HandleFunc("GET "+boardPath, read(boardFunc))
+	mux.HandleFunc("GET /todo/events", read(eventsFunc))
+	mux.HandleFunc("POST /todo/items", write(checkCSRF(addFunc)))
+	mux.HandleFunc("GET /todo/items/{id}/edit", write(editFormFunc))
+	mux.HandleFunc("POST /todo/items/{id}", write(checkCSRF(editFunc)))
+	mux.HandleFunc("POST /todo/items/{id}/status", write(checkCSRF(statusFunc)))
+	mux.HandleFunc("POST /todo/items/{id}/delete", write(checkCSRF(deleteFunc)))
+	mux.HandleFunc("GET /login", loginFormFunc)
+	mux.HandleFunc("POST /login", checkCSRF(loginFunc))
+	mux.HandleFunc("POST /logout", checkCSRF(logoutFunc))
+
+	// Serve static files for the /about endpoint and the board script
+	mux.Handle("/static/", http.FileServer(http.FS(static)))
+	return mux
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// Cookies and form fields of browser sessions.
+const (
+	csrfCookie  = "todo_csrf"
+	csrfField   = "csrf_token"
+	flashCookie = "todo_flash"
+)
+
+// Kinds of flash messages.
+const (
+	flashSuccess = "success"
+	flashError   = "error"
+)
+
+// flash is a message shown once, on the page a form post redirects to.
+type flash struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// csrfToken returns the CSRF token of the browser, given to it in a cookie
+// the first time. Forms send it back in the csrfField field.
+func csrfToken(res http.ResponseWriter, req *http.Request) string {
+	if cookie, err := req.Cookie(csrfCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	http.SetCookie(res, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return token
+}
+
+// checkCSRF rejects form posts whose csrfField does not match the CSRF cookie,
+// so other sites cannot submit forms on behalf of a signed in browser.
+func checkCSRF(next http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		cookie, err := req.Cookie(csrfCookie)
+		if err != nil || cookie.Value == "" ||
+			subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(req.PostFormValue(csrfField))) != 1 {
+			slog.WarnContext(req.Context(), "Form post rejected, invalid CSRF token.", "path", req.URL.Path)
+			http.Error(res, "The form has expired, reload the page and try again.", http.StatusForbidden)
+			return
+		}
+		next(res, req)
+	}
+}
+
+// setFlash keeps a message for the next page the browser loads.
+func setFlash(res http.ResponseWriter, req *http.Request, kind string, message string) {
+	value, _ := json.Marshal(flash{Kind: kind, Message: message})
+	http.SetCookie(res, &http.Cookie{
+		Name:     flashCookie,
+		Value:    base64.RawURLEncoding.EncodeToString(value),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// takeFlash returns the flash message kept for this page and clears it.
+func takeFlash(res http.ResponseWriter, req *http.Request) *flash {
+	cookie, err := req.Cookie(flashCookie)
+	if err != nil {
+		return nil
+	}
+	http.SetCookie(res, &http.Cookie{
+		Name:     flashCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	var message flash
+	value, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err == nil {
+		err = json.Unmarshal(value, &message)
+	}
+	if err != nil || message.Message == "" {
+		return nil
+	}
+	return &message
+}
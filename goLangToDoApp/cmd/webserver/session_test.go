@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"goLangToDoApp/pkg/auth"
+	"goLangToDoApp/pkg/base"
+	"goLangToDoApp/pkg/config"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestMux serves the pages on a list in a temporary directory, with
+// authentication as given by authMode.
+func newTestMux(t *testing.T, authMode string) (*http.ServeMux, config.Config) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	cfg := config.Default()
+	cfg.DataFile = filepath.Join(t.TempDir(), "ToDoData.json")
+	cfg.Store = base.StoreActor
+	cfg.Auth = authMode
+	cfg.TokensFile = filepath.Join(t.TempDir(), "tokens.json")
+
+	var err error
+	templates, err = newTemplateSet("")
+	if err != nil {
+		t.Fatalf("Failed to parse templates: %v", err)
+	}
+	stores = base.NewUserStores(cfg)
+	t.Cleanup(func() {
+		if err := stores.Close(context.Background()); err != nil {
+			t.Errorf("Failed to close stores: %v", err)
+		}
+	})
+	authenticator = auth.NewAuthenticator(cfg)
+	authenticator.ErrorHandler = authErrorFunc
+	return newMux(), cfg
+}
+
+// postForm posts form to target, sending the CSRF cookie when it is set.
+func postForm(mux *http.ServeMux, target string, form url.Values, cookie string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: csrfCookie, Value: cookie})
+	}
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+	return res
+}
+
+// responseCookie returns the cookie name set by res.
+func responseCookie(res *httptest.ResponseRecorder, name string) (*http.Cookie, bool) {
+	for _, cookie := range res.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie, true
+		}
+	}
+	return nil, false
+}
+
+func TestCheckCSRF(t *testing.T) {
+	mux, _ := newTestMux(t, config.AuthDisabled)
+
+	tests := []struct {
+		name   string
+		cookie string
+		field  string
+		status int
+	}{
+		{"MissingToken", "", "", http.StatusForbidden},
+		{"MissingCookie", "", "secret", http.StatusForbidden},
+		{"MissingField", "secret", "", http.StatusForbidden},
+		{"MismatchedToken", "secret", "other", http.StatusForbidden},
+		{"ValidToken", "secret", "secret", http.StatusSeeOther},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			form := url.Values{"description": {"Buy milk"}}
+			if tc.field != "" {
+				form.Set(csrfField, tc.field)
+			}
+			res := postForm(mux, "/todo/items", form, tc.cookie)
+			if res.Code != tc.status {
+				t.Errorf("Expected %d, got %d %s", tc.status, res.Code, res.Body.String())
+			}
+		})
+	}
+
+	items, err := stores.Store(context.Background(), base.DefaultUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := items.GetAllToDoItems(context.Background()); len(all) != 1 {
+		t.Errorf("Expected only the valid post to add an item, got %d", len(all))
+	}
+}
+
+func TestPostRedirectGetFlash(t *testing.T) {
+	mux, _ := newTestMux(t, config.AuthDisabled)
+
+	form := url.Values{"description": {"Buy milk"}, csrfField: {"secret"}}
+	res := postForm(mux, "/todo/items", form, "secret")
+	if res.Code != http.StatusSeeOther || res.Header().Get("Location") != listPath {
+		t.Fatalf("Expected 303 to %s, got %d %q", listPath, res.Code, res.Header().Get("Location"))
+	}
+	flashSet, ok := responseCookie(res, flashCookie)
+	if !ok || flashSet.Value == "" {
+		t.Fatalf("Expected the post to set a flash message")
+	}
+
+	// The page the post redirects to shows the message and clears it.
+	req := httptest.NewRequest("GET", listPath, nil)
+	req.AddCookie(flashSet)
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `class="flash success"`) {
+		t.Errorf("Expected the success message on the list, got %d %s", res.Code, res.Body.String())
+	}
+	cleared, ok := responseCookie(res, flashCookie)
+	if !ok || cleared.MaxAge >= 0 {
+		t.Errorf("Expected the flash cookie to be cleared, got %+v", cleared)
+	}
+
+	// Without the cookie, as the browser sends the next request, the message
+	// is gone.
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest("GET", listPath, nil))
+	if strings.Contains(res.Body.String(), `class="flash`) {
+		t.Errorf("Expected the message to be shown once")
+	}
+	if _, ok := responseCookie(res, flashCookie); ok {
+		t.Errorf("Expected no flash cookie without a message")
+	}
+}
+
+func TestSignOutOnlyWithAuth(t *testing.T) {
+	mux, _ := newTestMux(t, config.AuthDisabled)
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest("GET", listPath, nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("Expected the list, got %d", res.Code)
+	}
+	if strings.Contains(res.Body.String(), "Sign out") {
+		t.Errorf("Expected no sign out button when authentication is disabled")
+	}
+
+	mux, cfg := newTestMux(t, config.AuthRequired)
+	token, _, err := auth.NewTokenStore(cfg.TokensFile).Mint("alice", []string{auth.ScopeRead}, 0, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to mint token: %v", err)
+	}
+	req := httptest.NewRequest("GET", listPath, nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "Sign out") ||
+		!strings.Contains(res.Body.String(), "Signed in as alice") {
+		t.Errorf("Expected the sign out button for a signed in user, got %d %s", res.Code, res.Body.String())
+	}
+}
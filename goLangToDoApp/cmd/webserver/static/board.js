@@ -0,0 +1,35 @@
+// Drag and drop for the board. Dropping a card on a column submits the card's
+// move form for that column, so the board works the same without JavaScript.
+document.querySelectorAll(".card[draggable=true]").forEach(function (card) {
+    card.addEventListener("dragstart", function (event) {
+        event.dataTransfer.setData("text/plain", card.id);
+    });
+});
+
+document.querySelectorAll(".column").forEach(function (column) {
+    column.addEventListener("dragover", function (event) {
+        event.preventDefault();
+        column.classList.add("over");
+    });
+    column.addEventListener("dragleave", function () {
+        column.classList.remove("over");
+    });
+    column.addEventListener("drop", function (event) {
+        event.preventDefault();
+        column.classList.remove("over");
+        var card = document.getElementById(event.dataTransfer.getData("text/plain"));
+        if (!card) {
+            return;
+        }
+        var status = column.dataset.status;
+        var moves = card.dataset.moves ? card.dataset.moves.split(",") : [];
+        if (moves.indexOf(status) < 0) {
+            return;
+        }
+        card.querySelectorAll("form").forEach(function (form) {
+            if (form.elements.status.value === status) {
+                form.submit();
+            }
+        });
+    });
+});
@@ -0,0 +1,33 @@
+// Live updates for the list and the board. The page reloads when the list
+// changes, unless a form has been edited, then it offers a reload instead so
+// the input is not lost.
+(function () {
+    if (!window.EventSource) {
+        return;
+    }
+
+    var edited = false;
+    document.addEventListener("input", function () {
+        edited = true;
+    });
+
+    function changed() {
+        if (!edited) {
+            window.location.reload();
+            return;
+        }
+        if (document.getElementById("live-notice")) {
+            return;
+        }
+        var notice = document.createElement("p");
+        notice.id = "live-notice";
+        notice.className = "flash";
+        notice.innerHTML = 'The list has changed. <a href="">Reload</a>';
+        document.body.insertBefore(notice, document.body.firstChild);
+    }
+
+    var source = new EventSource("/todo/events");
+    ["add", "update", "delete"].forEach(function (op) {
+        source.addEventListener(op, changed);
+    });
+})();
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+)
+
+var (
+	//go:embed dynamic
+	dynamic embed.FS
+)
+
+// Every page is parsed together with the shared layout and partials. A page
+// defines the "title" and "content" templates the layout renders.
+const (
+	layoutFile   = "layout.html"
+	partialsFile = "partials.html"
+)
+
+// templateSet holds the parsed page templates by file name.
+type templateSet struct {
+	// dir, when set, is parsed again on every render so template edits show
+	// without restarting the server.
+	dir   string
+	pages map[string]*template.Template
+}
+
+// newTemplateSet parses the embedded templates, or the templates in dir when
+// it is set.
+func newTemplateSet(dir string) (*templateSet, error) {
+	set := &templateSet{dir: dir}
+	var err error
+	if dir != "" {
+		set.pages, err = parseTemplates(os.DirFS(dir))
+		return set, err
+	}
+	embedded, err := fs.Sub(dynamic, "dynamic")
+	if err != nil {
+		return nil, err
+	}
+	set.pages, err = parseTemplates(embedded)
+	return set, err
+}
+
+// parseTemplates parses every page in fsys with the layout and partials.
+func parseTemplates(fsys fs.FS) (map[string]*template.Template, error) {
+	shared, err := template.ParseFS(fsys, layoutFile, partialsFile)
+	if err != nil {
+		return nil, err
+	}
+	names, err := fs.Glob(fsys, "*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[string]*template.Template)
+	for _, name := range names {
+		if name == layoutFile || name == partialsFile {
+			continue
+		}
+		page, err := template.Must(shared.Clone()).ParseFS(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		pages[path.Base(name)] = page
+	}
+	return pages, nil
+}
+
+// execute renders the page name with data.
+func (set *templateSet) execute(name string, data any) ([]byte, error) {
+	pages := set.pages
+	if set.dir != "" {
+		var err error
+		pages, err = parseTemplates(os.DirFS(set.dir))
+		if err != nil {
+			return nil, err
+		}
+	}
+	page, ok := pages[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+
+	var buf bytes.Buffer
+	err := page.ExecuteTemplate(&buf, layoutFile, data)
+	return buf.Bytes(), err
+}
+
+// render writes the page name with data. The page is rendered before writing,
+// so a failing template results in an error page instead of a partial one.
+func render(res http.ResponseWriter, req *http.Request, status int, name string, data any) {
+	body, err := templates.execute(name, data)
+	if err != nil {
+		serverError(res, req, "Failed to render page.", err)
+		return
+	}
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	res.WriteHeader(status)
+	_, _ = res.Write(body)
+}
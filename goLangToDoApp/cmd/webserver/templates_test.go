@@ -0,0 +1,57 @@
+package main
+
+import (
+	"goLangToDoApp/pkg/core"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplatesExecute(t *testing.T) {
+	set, err := newTemplateSet("")
+	if err != nil {
+		t.Fatalf("Failed to parse the embedded templates: %v", err)
+	}
+
+	due := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	item := core.Item{
+		ItemId:      1,
+		UUID:        "0a1b2c3d-0000-4000-8000-000000000001",
+		Status:      core.Statuses[0],
+		Description: "Buy <milk>",
+		Priority:    "high",
+		Due:         &due,
+		Tags:        []string{"home"},
+	}
+	base := page{Auth: true, User: "alice", CSRF: "token", Flash: &flash{Kind: flashSuccess, Message: "Saved."}, Error: "Oops."}
+	pages := map[string]any{
+		"list.html": listPage{page: base, Items: []core.Item{item}, CanWrite: true,
+			Statuses: core.Statuses, Priorities: core.Priorities, Form: itemFormOf(item)},
+		"edit.html": editPage{page: base, Item: item, Statuses: core.Statuses,
+			Priorities: core.Priorities, Form: itemFormOf(item)},
+		"board.html": boardPage{page: base, Columns: boardColumns([]core.Item{item}), CanWrite: true},
+		"login.html": loginPage{page: base, Next: listPath},
+	}
+	if len(set.pages) != len(pages) {
+		t.Errorf("Expected %d pages, got %d", len(pages), len(set.pages))
+	}
+	for name := range set.pages {
+		data, ok := pages[name]
+		if !ok {
+			t.Errorf("No sample data for page %s", name)
+			continue
+		}
+		body, err := set.execute(name, data)
+		if err != nil {
+			t.Errorf("Failed to execute %s: %v", name, err)
+			continue
+		}
+		html := string(body)
+		if !strings.HasPrefix(html, "<!DOCTYPE html>") || !strings.Contains(html, "</html>") {
+			t.Errorf("Expected %s to be rendered in the layout", name)
+		}
+		if strings.Contains(html, "<milk>") {
+			t.Errorf("Expected %s to escape the item description", name)
+		}
+	}
+}
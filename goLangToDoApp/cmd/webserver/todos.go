@@ -0,0 +1,353 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/auth"
+	"goLangToDoApp/pkg/base"
+	"goLangToDoApp/pkg/core"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const listPath = "/todo/list"
+
+// page is the data shared by the templates.
+type page struct {
+	// Auth is set unless authentication is disabled, so there is no sign in.
+	Auth  bool
+	User  string
+	CSRF  string
+	Flash *flash
+	Error string
+}
+
+// listPage is the data of the list template.
+type listPage struct {
+	page
+	Items      []core.Item
+	CanWrite   bool
+	Statuses   []string
+	Priorities []string
+	// Form holds the add form, filled in again when it was rejected.
+	Form itemForm
+}
+
+// editPage is the data of the edit template.
+type editPage struct {
+	page
+	Item       core.Item
+	Statuses   []string
+	Priorities []string
+	Form       itemForm
+}
+
+// itemForm holds the fields of the add and edit forms as they were entered.
+type itemForm struct {
+	Description string
+	Status      string
+	Priority    string
+	Due         string
+	Tags        string
+}
+
+func readItemForm(req *http.Request) itemForm {
+	return itemForm{
+		Description: strings.TrimSpace(req.PostFormValue("description")),
+		Status:      req.PostFormValue("status"),
+		Priority:    req.PostFormValue("priority"),
+		Due:         strings.TrimSpace(req.PostFormValue("due")),
+		Tags:        req.PostFormValue("tags"),
+	}
+}
+
+// itemFormOf returns the edit form filled in with the fields of item.
+func itemFormOf(item core.Item) itemForm {
+	form := itemForm{
+		Description: item.Description,
+		Status:      item.Status,
+		Priority:    item.Priority,
+		Tags:        strings.Join(item.Tags, ", "),
+	}
+	if item.Due != nil {
+		form.Due = item.Due.Format(core.DateLayout)
+	}
+	return form
+}
+
+// item validates the add form and returns the fields of the new item.
+func (form itemForm) item() (core.Item, error) {
+	if form.Description == "" {
+		return core.Item{}, fmt.Errorf("%w: description is required", core.ErrValidation)
+	}
+	due, err := core.ParseDue(form.Due)
+	if err != nil {
+		return core.Item{}, err
+	}
+
+	fields := core.Item{Description: form.Description, Priority: form.Priority, Tags: core.ParseTags(form.Tags)}
+	if !due.IsZero() {
+		fields.Due = &due
+	}
+	return fields, nil
+}
+
+// patch validates the edit form and returns the patch replacing every field.
+func (form itemForm) patch() (core.ItemPatch, error) {
+	if form.Description == "" {
+		return core.ItemPatch{}, fmt.Errorf("%w: description is required", core.ErrValidation)
+	}
+	due, err := core.ParseDue(form.Due)
+	if err != nil {
+		return core.ItemPatch{}, err
+	}
+	return core.ItemPatch{
+		Status:      &form.Status,
+		Description: &form.Description,
+		Priority:    &form.Priority,
+		Due:         &due,
+		Tags:        core.ParseTags(form.Tags),
+	}, nil
+}
+
+// newPage returns the shared template data of the request.
+func newPage(res http.ResponseWriter, req *http.Request) page {
+	p := page{Auth: !authenticator.Disabled, CSRF: csrfToken(res, req), Flash: takeFlash(res, req)}
+	if principal, ok := auth.PrincipalFrom(req.Context()); ok {
+		p.User = principal.User
+	}
+	return p
+}
+
+// userStore returns the store of the signed in user, the default list when
+// authentication is disabled.
+func userStore(req *http.Request) (core.Store, error) {
+	return stores.Store(req.Context(), signedInUser(req))
+}
+
+// signedInUser returns the signed in user, the default user when
+// authentication is disabled.
+func signedInUser(req *http.Request) string {
+	if principal, ok := auth.PrincipalFrom(req.Context()); ok {
+		return principal.User
+	}
+	return base.DefaultUser
+}
+
+// canWrite reports whether the request may change the list.
+func canWrite(req *http.Request) bool {
+	principal, ok := auth.PrincipalFrom(req.Context())
+	return !ok || principal.Allows(auth.ScopeWrite)
+}
+
+// formError returns the status and message shown for err when the submitted
+// form caused it. ok is false when the server failed instead.
+func formError(err error) (status int, message string, ok bool) {
+	status, _ = base.ErrorStatus(err)
+	return status, err.Error(), status < http.StatusInternalServerError
+}
+
+func serverError(res http.ResponseWriter, req *http.Request, msg string, err error) {
+	http.Error(res, msg, http.StatusInternalServerError)
+	slog.ErrorContext(req.Context(), msg, "error", err)
+}
+
+// redirect sends the browser back with a flash message, the redirect part of
+// post/redirect/get. Forms name the page to return to in a next field, the
+// list by default.
+func redirect(res http.ResponseWriter, req *http.Request, kind string, message string) {
+	setFlash(res, req, kind, message)
+	http.Redirect(res, req, localPath(req.PostFormValue("next")), http.StatusSeeOther)
+}
+
+func listFunc(res http.ResponseWriter, req *http.Request) {
+	renderList(res, req, http.StatusOK, itemForm{}, "")
+}
+
+// renderList renders the list with the add form and an optional error.
+func renderList(res http.ResponseWriter, req *http.Request, status int, form itemForm, message string) {
+	store, err := userStore(req)
+	var items []core.Item
+	if err == nil {
+		items, err = store.GetAllToDoItems(req.Context())
+	}
+	if err != nil {
+		serverError(res, req, "Failed to get all To-Do Items.", err)
+		return
+	}
+
+	data := listPage{
+		page:       newPage(res, req),
+		Items:      items,
+		CanWrite:   canWrite(req),
+		Statuses:   core.Statuses,
+		Priorities: core.Priorities,
+		Form:       form,
+	}
+	data.Error = message
+	render(res, req, status, "list.html", data)
+}
+
+func addFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	form := readItemForm(req)
+	store, err := userStore(req)
+	if err != nil {
+		serverError(res, req, "Failed to add To-Do Item.", err)
+		return
+	}
+
+	fields, err := form.item()
+	var item core.Item
+	if err == nil {
+		item, err = store.AddToDoItem(ctx, fields)
+	}
+	if err != nil {
+		status, message, ok := formError(err)
+		if !ok {
+			serverError(res, req, "Failed to add To-Do Item.", err)
+			return
+		}
+		slog.InfoContext(ctx, "Add form rejected.", "error", err)
+		renderList(res, req, status, form, message)
+		return
+	}
+
+	slog.InfoContext(ctx, "Created new To-Do Item successfully", "Id", item.ItemId)
+	redirect(res, req, flashSuccess, fmt.Sprintf("Added To-Do Item %d.", item.ItemId))
+}
+
+// findItem returns the store of the request and the item named by its path.
+func findItem(req *http.Request) (core.Store, core.Item, error) {
+	store, err := userStore(req)
+	if err != nil {
+		return nil, core.Item{}, err
+	}
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		return nil, core.Item{}, fmt.Errorf("To-Do Item %q: %w", req.PathValue("id"), core.ErrNotFound)
+	}
+	items, err := store.GetAllToDoItems(req.Context())
+	if err != nil {
+		return nil, core.Item{}, err
+	}
+	for _, item := range items {
+		if item.ItemId == id {
+			return store, item, nil
+		}
+	}
+	return nil, core.Item{}, core.NotFoundError(id)
+}
+
+func editFormFunc(res http.ResponseWriter, req *http.Request) {
+	_, item, err := findItem(req)
+	if errors.Is(err, core.ErrNotFound) {
+		redirect(res, req, flashError, err.Error())
+		return
+	}
+	if err != nil {
+		serverError(res, req, "Failed to get To-Do Item.", err)
+		return
+	}
+	renderEdit(res, req, http.StatusOK, item, itemFormOf(item), "")
+}
+
+func renderEdit(res http.ResponseWriter, req *http.Request, status int, item core.Item, form itemForm, message string) {
+	data := editPage{
+		page:       newPage(res, req),
+		Item:       item,
+		Statuses:   core.Statuses,
+		Priorities: core.Priorities,
+		Form:       form,
+	}
+	data.Error = message
+	render(res, req, status, "edit.html", data)
+}
+
+func editFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	form := readItemForm(req)
+	store, item, err := findItem(req)
+	if err == nil {
+		var patch core.ItemPatch
+		patch, err = form.patch()
+		if err == nil {
+			_, err = store.PatchToDoItem(ctx, item.ItemId, patch)
+		}
+	}
+	if errors.Is(err, core.ErrNotFound) {
+		redirect(res, req, flashError, err.Error())
+		return
+	}
+	if err != nil {
+		status, message, ok := formError(err)
+		if !ok {
+			serverError(res, req, "Failed to update To-Do Item.", err)
+			return
+		}
+		slog.InfoContext(ctx, "Edit form rejected.", "error", err)
+		renderEdit(res, req, status, item, form, message)
+		return
+	}
+
+	slog.InfoContext(ctx, "Updated To-Do Item successfully.", "Id", item.ItemId)
+	redirect(res, req, flashSuccess, fmt.Sprintf("Updated To-Do Item %d.", item.ItemId))
+}
+
+// statusFunc changes only the status, from the list and the board.
+func statusFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	status := req.PostFormValue("status")
+	store, item, err := findItem(req)
+	if err == nil && status == "" {
+		err = fmt.Errorf("%w: status is required", core.ErrValidation)
+	}
+	if err == nil {
+		err = store.UpdateToDoItem(ctx, item.ItemId, status, "")
+	}
+	if err != nil {
+		if _, message, ok := formError(err); ok {
+			redirect(res, req, flashError, message)
+			return
+		}
+		serverError(res, req, "Failed to update To-Do Item.", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Updated To-Do Item successfully.", "Id", item.ItemId, "status", status)
+	redirect(res, req, flashSuccess, fmt.Sprintf("To-Do Item %d is now %s.", item.ItemId, status))
+}
+
+func deleteFunc(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	store, item, err := findItem(req)
+	if err == nil {
+		err = store.DeleteToDoItem(ctx, item.ItemId)
+	}
+	if err != nil {
+		if _, message, ok := formError(err); ok {
+			redirect(res, req, flashError, message)
+			return
+		}
+		serverError(res, req, "Failed to delete To-Do Item.", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Deleted To-Do Item successfully.", "Id", item.ItemId)
+	redirect(res, req, flashSuccess, fmt.Sprintf("Deleted To-Do Item %d.", item.ItemId))
+}
+
+// eventsFunc streams the changes of the list to the open pages, see
+// static/live.js.
+func eventsFunc(res http.ResponseWriter, req *http.Request) {
+	// Keep the store open for as long as the stream runs
+	store, release, err := stores.Hold(req.Context(), signedInUser(req))
+	if err != nil {
+		serverError(res, req, "Failed to get To-Do Items.", err)
+		return
+	}
+	defer release()
+	events.Serve(res, req, store)
+}
@@ -0,0 +1,71 @@
+// Package auth authenticates API requests with locally issued API tokens or
+// HMAC-signed JWTs and authorizes them by scope.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"slices"
+	"strings"
+)
+
+// Scopes granted to a token. ScopeWrite implies ScopeRead.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// Errors of requests failing authentication or authorization, wrapped with
+// details. Match them with errors.Is.
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// Principal is the authenticated identity of a request.
+type Principal struct {
+	// User owns the list the principal may access, empty for the default list.
+	User string
+	// Scopes lists the granted scopes.
+	Scopes []string
+	// TokenId identifies the API token or JWT used.
+	TokenId string
+}
+
+// Allows reports whether the principal has been granted scope.
+func (principal Principal) Allows(scope string) bool {
+	return slices.Contains(principal.Scopes, scope) ||
+		(scope == ScopeRead && slices.Contains(principal.Scopes, ScopeWrite))
+}
+
+// ParseScopes parses a comma or space separated list of scopes.
+func ParseScopes(s string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if scope != ScopeRead && scope != ScopeWrite {
+			return nil, fmt.Errorf("%w: unknown scope %q, expected %q or %q", core.ErrValidation, scope, ScopeRead, ScopeWrite)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", core.ErrValidation)
+	}
+	return scopes, nil
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal stored in ctx by the middleware.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// jwtHeader is the only header accepted, HS256 signed JWTs.
+const jwtHeader = `{"alg":"HS256","typ":"JWT"}`
+
+// Claims are the JWT claims understood by the API.
+type Claims struct {
+	Subject   string `json:"sub"`
+	Scope     string `json:"scope"`
+	Id        string `json:"jti,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SignJWT returns an HS256 JWT for user with scopes, expiring after ttl.
+func SignJWT(secret []byte, user string, scopes []string, ttl time.Duration, now time.Time) (string, error) {
+	if ttl <= 0 {
+		return "", fmt.Errorf("%w: a JWT must expire", core.ErrValidation)
+	}
+	claims := Claims{
+		Subject:   user,
+		Scope:     strings.Join(scopes, " "),
+		Id:        uuid.NewString(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling claims: %w", err)
+	}
+
+	signingInput := encodeSegment([]byte(jwtHeader)) + "." + encodeSegment(payload)
+	return signingInput + "." + encodeSegment(sign(secret, signingInput)), nil
+}
+
+// VerifyJWT checks the signature and validity period of an HS256 JWT and
+// returns its principal.
+func VerifyJWT(secret []byte, raw string, now time.Time) (Principal, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return Principal{}, fmt.Errorf("%w: malformed JWT", ErrUnauthorized)
+	}
+
+	header, err := decodeSegment(parts[0])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed JWT header", ErrUnauthorized)
+	}
+	var alg struct {
+		Alg string `json:"alg"`
+	}
+	if json.Unmarshal(header, &alg) != nil || alg.Alg != "HS256" {
+		return Principal{}, fmt.Errorf("%w: JWT must be signed with HS256", ErrUnauthorized)
+	}
+
+	signature, err := decodeSegment(parts[2])
+	if err != nil || !hmac.Equal(signature, sign(secret, parts[0]+"."+parts[1])) {
+		return Principal{}, fmt.Errorf("%w: invalid JWT signature", ErrUnauthorized)
+	}
+
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed JWT claims", ErrUnauthorized)
+	}
+	var claims Claims
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed JWT claims", ErrUnauthorized)
+	}
+	unix := now.Unix()
+	if claims.ExpiresAt == 0 || unix >= claims.ExpiresAt || unix < claims.NotBefore {
+		return Principal{}, fmt.Errorf("%w: JWT is expired or not yet valid", ErrUnauthorized)
+	}
+	scopes, err := ParseScopes(claims.Scope)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: invalid JWT scope %q", ErrUnauthorized, claims.Scope)
+	}
+	return Principal{User: claims.Subject, Scopes: scopes, TokenId: claims.Id}, nil
+}
+
+func sign(secret []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(segment)
+}
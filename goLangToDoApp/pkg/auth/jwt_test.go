@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestJWT(t *testing.T) {
+	now := time.Now()
+	raw, err := SignJWT(testSecret, "bob", []string{ScopeWrite}, time.Hour, now)
+	if err != nil {
+		t.Fatalf("Failed to sign JWT: %v", err)
+	}
+
+	principal, err := VerifyJWT(testSecret, raw, now)
+	if err != nil || principal.User != "bob" || !principal.Allows(ScopeRead) || !principal.Allows(ScopeWrite) {
+		t.Errorf("Unexpected principal %+v (%v)", principal, err)
+	}
+
+	parts := strings.Split(raw, ".")
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	tests := map[string]struct {
+		secret []byte
+		raw    string
+		now    time.Time
+	}{
+		"wrong secret": {[]byte(strings.Repeat("x", 32)), raw, now},
+		"expired":      {testSecret, raw, now.Add(2 * time.Hour)},
+		"tampered":     {testSecret, parts[0] + "." + parts[1] + "e30." + parts[2], now},
+		"alg none":     {testSecret, none + "." + parts[1] + ".", now},
+		"malformed":    {testSecret, "a.b", now},
+	}
+	for name, tc := range tests {
+		if _, err := VerifyJWT(tc.secret, tc.raw, tc.now); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("%s: expected ErrUnauthorized, got %v", name, err)
+		}
+	}
+
+	if _, err := SignJWT(testSecret, "bob", []string{ScopeRead}, 0, now); err == nil {
+		t.Errorf("Expected error for a JWT without expiry")
+	}
+}
+
+func TestRequire(t *testing.T) {
+	authenticator := &Authenticator{
+		Secret: testSecret,
+		ErrorHandler: func(res http.ResponseWriter, _ *http.Request, err error) {
+			status := http.StatusUnauthorized
+			if errors.Is(err, ErrForbidden) {
+				status = http.StatusForbidden
+			}
+			res.WriteHeader(status)
+		},
+	}
+	var seen Principal
+	handler := authenticator.Require(ScopeWrite, func(_ http.ResponseWriter, req *http.Request) {
+		seen, _ = PrincipalFrom(req.Context())
+	})
+	serve := func(scope string, cookie bool) int {
+		req := httptest.NewRequest(http.MethodPost, "/todos", nil)
+		if scope != "" {
+			raw, err := SignJWT(testSecret, "carol", []string{scope}, time.Hour, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cookie {
+				req.AddCookie(&http.Cookie{Name: CookieName, Value: raw})
+			} else {
+				req.Header.Set("Authorization", "Bearer "+raw)
+			}
+		}
+		res := httptest.NewRecorder()
+		handler(res, req)
+		return res.Code
+	}
+
+	if code := serve("", false); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", code)
+	}
+	if code := serve(ScopeRead, false); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a read-only token, got %d", code)
+	}
+	if code := serve(ScopeWrite, true); code != http.StatusOK || seen.User != "carol" {
+		t.Errorf("Expected write token to pass, got %d for %+v", code, seen)
+	}
+
+	authenticator.Disabled = true
+	if code := serve("", false); code != http.StatusOK {
+		t.Errorf("Expected requests to pass with authentication disabled, got %d", code)
+	}
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/config"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CookieName is the cookie holding the token of browser sessions.
+const CookieName = "todo_token"
+
+// Authenticator verifies the credentials of requests, given as a bearer token
+// in the Authorization header or in the CookieName cookie.
+type Authenticator struct {
+	// Disabled lets every request through without a principal.
+	Disabled bool
+	// Tokens verifies API tokens, nil rejects them.
+	Tokens *TokenStore
+	// Secret verifies JWTs, empty rejects them.
+	Secret []byte
+	// ErrorHandler writes the response of rejected requests.
+	ErrorHandler func(res http.ResponseWriter, req *http.Request, err error)
+}
+
+// NewAuthenticator initializes the Authenticator configured by cfg.
+func NewAuthenticator(cfg config.Config) *Authenticator {
+	authenticator := &Authenticator{
+		Disabled: cfg.Auth == config.AuthDisabled,
+		Tokens:   NewTokenStore(cfg.TokensFile),
+		ErrorHandler: func(res http.ResponseWriter, _ *http.Request, err error) {
+			status := http.StatusUnauthorized
+			if errors.Is(err, ErrForbidden) {
+				status = http.StatusForbidden
+			}
+			http.Error(res, err.Error(), status)
+		},
+	}
+	if cfg.JWTSecret != "" {
+		authenticator.Secret = []byte(cfg.JWTSecret)
+	}
+	return authenticator
+}
+
+// Authenticate returns the principal of the request credentials.
+func (authenticator *Authenticator) Authenticate(req *http.Request) (Principal, error) {
+	raw := ""
+	if header := req.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return Principal{}, fmt.Errorf("%w: expected a Bearer token", ErrUnauthorized)
+		}
+		raw = strings.TrimSpace(token)
+	} else if cookie, err := req.Cookie(CookieName); err == nil {
+		raw = cookie.Value
+	}
+
+	return authenticator.Verify(raw)
+}
+
+// Verify returns the principal of a raw API token or JWT.
+func (authenticator *Authenticator) Verify(raw string) (Principal, error) {
+	now := time.Now()
+	switch {
+	case raw == "":
+		return Principal{}, fmt.Errorf("%w: missing credentials", ErrUnauthorized)
+	case strings.HasPrefix(raw, TokenPrefix) && authenticator.Tokens != nil:
+		return authenticator.Tokens.Verify(raw, now)
+	case strings.Count(raw, ".") == 2 && len(authenticator.Secret) > 0:
+		return VerifyJWT(authenticator.Secret, raw, now)
+	default:
+		return Principal{}, fmt.Errorf("%w: unsupported credentials", ErrUnauthorized)
+	}
+}
+
+// Require only lets requests through whose principal was granted scope, and
+// stores the principal in the request context.
+func (authenticator *Authenticator) Require(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if authenticator.Disabled {
+			next(res, req)
+			return
+		}
+
+		principal, err := authenticator.Authenticate(req)
+		if err == nil && !principal.Allows(scope) {
+			err = fmt.Errorf("%w: token lacks the %s scope", ErrForbidden, scope)
+		}
+		if err != nil {
+			if errors.Is(err, ErrUnauthorized) {
+				res.Header().Set("WWW-Authenticate", `Bearer realm="todo"`)
+			}
+			authenticator.ErrorHandler(res, req, err)
+			return
+		}
+		next(res, req.WithContext(WithPrincipal(req.Context(), principal)))
+	}
+}
@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenPrefix starts every API token, which reads "todo_<id>_<secret>".
+const TokenPrefix = "todo_"
+
+// tokenIdBytes is the number of random bytes of a token id, and mintAttempts
+// the number of ids Mint tries before giving up on finding an unused one.
+const (
+	tokenIdBytes = 8
+	mintAttempts = 3
+)
+
+// newTokenId returns a random token id. Tests replace it to force collisions.
+var newTokenId = func() (string, error) {
+	idBytes := make([]byte, tokenIdBytes)
+	_, err := rand.Read(idBytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(idBytes), nil
+}
+
+// Token is the record of an API token. Only the SHA-256 hash of the secret is
+// kept, the token itself is shown once when it is minted.
+type Token struct {
+	Id        string     `json:"id"`
+	User      string     `json:"user"`
+	Scopes    []string   `json:"scopes"`
+	Hash      string     `json:"hash"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the token can be used at now.
+func (token Token) Active(now time.Time) bool {
+	return token.RevokedAt == nil && (token.ExpiresAt == nil || now.Before(*token.ExpiresAt))
+}
+
+// TokenStore keeps the API tokens in a JSON file. The file is reloaded when it
+// changes, so tokens minted or revoked by the CLI apply to running servers.
+type TokenStore struct {
+	filePath string
+	mu       sync.Mutex
+	tokens   []Token
+	modTime  time.Time
+	size     int64
+}
+
+// NewTokenStore initializes a TokenStore for filePath.
+func NewTokenStore(filePath string) *TokenStore {
+	return &TokenStore{filePath: filePath}
+}
+
+// Mint creates a token for user with scopes, valid for ttl or forever when
+// ttl is zero, and returns the token and its record.
+func (store *TokenStore) Mint(user string, scopes []string, ttl time.Duration, now time.Time) (string, Token, error) {
+	secretBytes := make([]byte, 32)
+	_, err := rand.Read(secretBytes)
+	if err != nil {
+		return "", Token{}, fmt.Errorf("error generating token: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	now = now.UTC()
+	token := Token{
+		User:      user,
+		Scopes:    slices.Clone(scopes),
+		Hash:      hashSecret(secret),
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		token.ExpiresAt = &expires
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	err = store.reload()
+	if err != nil {
+		return "", Token{}, err
+	}
+	token.Id, err = store.unusedId()
+	if err != nil {
+		return "", Token{}, err
+	}
+	store.tokens = append(store.tokens, token)
+	err = store.save()
+	if err != nil {
+		return "", Token{}, err
+	}
+	return TokenPrefix + token.Id + "_" + secret, token, nil
+}
+
+// unusedId returns a new token id which no token has, as Verify looks tokens
+// up by their id. The caller holds mu.
+func (store *TokenStore) unusedId() (string, error) {
+	for range mintAttempts {
+		id, err := newTokenId()
+		if err != nil {
+			return "", fmt.Errorf("error generating token: %w", err)
+		}
+		if !slices.ContainsFunc(store.tokens, func(token Token) bool { return token.Id == id }) {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("error generating token: %w: no unused id in %d attempts", core.ErrConflict, mintAttempts)
+}
+
+// Revoke marks the token with id as revoked.
+func (store *TokenStore) Revoke(id string, now time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	err := store.reload()
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(store.tokens, func(token Token) bool { return token.Id == id })
+	if index < 0 {
+		return fmt.Errorf("token %q: %w", id, core.ErrNotFound)
+	}
+	if store.tokens[index].RevokedAt == nil {
+		now = now.UTC()
+		store.tokens[index].RevokedAt = &now
+	}
+	return store.save()
+}
+
+// List returns every token record.
+func (store *TokenStore) List() ([]Token, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	err := store.reload()
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(store.tokens), nil
+}
+
+// Verify returns the principal of an active API token.
+func (store *TokenStore) Verify(raw string, now time.Time) (Principal, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(raw, TokenPrefix), "_")
+	if !strings.HasPrefix(raw, TokenPrefix) || !ok {
+		return Principal{}, fmt.Errorf("%w: malformed API token", ErrUnauthorized)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	err := store.reload()
+	if err != nil {
+		return Principal{}, err
+	}
+	for _, token := range store.tokens {
+		if token.Id != id {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hashSecret(secret))) != 1 || !token.Active(now) {
+			break
+		}
+		return Principal{User: token.User, Scopes: slices.Clone(token.Scopes), TokenId: token.Id}, nil
+	}
+	return Principal{}, fmt.Errorf("%w: invalid, expired or revoked API token", ErrUnauthorized)
+}
+
+// reload reads the token file when it changed since the last read.
+func (store *TokenStore) reload() error {
+	info, err := os.Stat(store.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		store.tokens, store.modTime, store.size = nil, time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading token file %s: %w", store.filePath, err)
+	}
+	if info.ModTime().Equal(store.modTime) && info.Size() == store.size {
+		return nil
+	}
+
+	byteValue, err := os.ReadFile(store.filePath)
+	if err != nil {
+		return fmt.Errorf("error reading token file %s: %w", store.filePath, err)
+	}
+	var tokens []Token
+	err = json.Unmarshal(byteValue, &tokens)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling token file %s: %w", store.filePath, err)
+	}
+	store.tokens, store.modTime, store.size = tokens, info.ModTime(), info.Size()
+	return nil
+}
+
+func (store *TokenStore) save() error {
+	byteValue, err := json.MarshalIndent(store.tokens, "", "\t")
+	if err != nil {
+		return fmt.Errorf("error marshalling tokens: %w", err)
+	}
+	err = os.MkdirAll(filepath.Dir(store.filePath), 0755)
+	if err == nil {
+		err = core.WriteFileAtomic(store.filePath, byteValue, 0600)
+	}
+	if err != nil {
+		return fmt.Errorf("error saving token file %s: %w", store.filePath, err)
+	}
+	// Force a reload so the next read sees exactly what was written.
+	store.modTime = time.Time{}
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
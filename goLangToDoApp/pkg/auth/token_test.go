@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"errors"
+	"goLangToDoApp/pkg/core"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenStoreMintVerify(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "tokens.json")
+	store := NewTokenStore(filePath)
+	now := time.Now()
+
+	raw, token, err := store.Mint("alice", []string{ScopeRead}, time.Hour, now)
+	if err != nil {
+		t.Fatalf("Failed to mint token: %v", err)
+	}
+	if !strings.HasPrefix(raw, TokenPrefix+token.Id+"_") {
+		t.Errorf("Unexpected token format %q", raw)
+	}
+
+	byteValue, _ := os.ReadFile(filePath)
+	secret := strings.TrimPrefix(raw, TokenPrefix+token.Id+"_")
+	if strings.Contains(string(byteValue), secret) {
+		t.Errorf("Token secret must not be stored in clear")
+	}
+
+	// A second store sees the token minted by the first, as a server does
+	// for tokens minted by the CLI.
+	principal, err := NewTokenStore(filePath).Verify(raw, now)
+	if err != nil || principal.User != "alice" || !principal.Allows(ScopeRead) || principal.Allows(ScopeWrite) {
+		t.Errorf("Unexpected principal %+v (%v)", principal, err)
+	}
+
+	for _, bad := range []string{raw + "x", TokenPrefix + token.Id + "_wrong", "todo_", "nonsense"} {
+		if _, err := store.Verify(bad, now); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("Expected ErrUnauthorized for %q, got %v", bad, err)
+		}
+	}
+	if _, err := store.Verify(raw, now.Add(2*time.Hour)); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected expired token to be rejected, got %v", err)
+	}
+}
+
+func TestTokenStoreMintCollision(t *testing.T) {
+	store := NewTokenStore(filepath.Join(t.TempDir(), "tokens.json"))
+	now := time.Now()
+	_, first, err := store.Mint("alice", []string{ScopeRead}, 0, now)
+	if err != nil {
+		t.Fatalf("Failed to mint token: %v", err)
+	}
+	if len(first.Id) != 2*tokenIdBytes {
+		t.Errorf("Expected a token id of %d random bytes, got %q", tokenIdBytes, first.Id)
+	}
+
+	// The first id drawn is taken, the next one is used.
+	ids := []string{first.Id, "0011223344556677"}
+	saved := newTokenId
+	newTokenId = func() (string, error) {
+		id := ids[0]
+		ids = ids[1:]
+		return id, nil
+	}
+	t.Cleanup(func() { newTokenId = saved })
+
+	raw, second, err := store.Mint("bob", []string{ScopeRead}, 0, now)
+	if err != nil || second.Id != "0011223344556677" {
+		t.Fatalf("Expected the mint to retry with a new id, got %q (%v)", second.Id, err)
+	}
+	if principal, err := store.Verify(raw, now); err != nil || principal.User != "bob" {
+		t.Errorf("Expected the token of bob, got %+v (%v)", principal, err)
+	}
+
+	newTokenId = func() (string, error) { return first.Id, nil }
+	if _, _, err := store.Mint("bob", []string{ScopeRead}, 0, now); !errors.Is(err, core.ErrConflict) {
+		t.Errorf("Expected ErrConflict when every id drawn is taken, got %v", err)
+	}
+}
+
+func TestTokenStoreRevoke(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "tokens.json")
+	store := NewTokenStore(filePath)
+	verifier := NewTokenStore(filePath)
+	now := time.Now()
+
+	raw, token, err := store.Mint("", []string{ScopeRead, ScopeWrite}, 0, now)
+	if err != nil {
+		t.Fatalf("Failed to mint token: %v", err)
+	}
+	if _, err := verifier.Verify(raw, now); err != nil {
+		t.Fatalf("Expected valid token, got %v", err)
+	}
+
+	if err := store.Revoke(token.Id, now); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if _, err := verifier.Verify(raw, now); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected revoked token to be rejected, got %v", err)
+	}
+	if err := store.Revoke("missing", now); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown token, got %v", err)
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes("read, write read")
+	if err != nil || len(scopes) != 2 {
+		t.Errorf("Unexpected scopes %v (%v)", scopes, err)
+	}
+	for _, bad := range []string{"", "admin", "read,admin"} {
+		if _, err := ParseScopes(bad); !errors.Is(err, core.ErrValidation) {
+			t.Errorf("Expected ErrValidation for %q, got %v", bad, err)
+		}
+	}
+}
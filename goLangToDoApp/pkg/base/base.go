@@ -2,57 +2,68 @@ package base
 
 import (
 	"context"
-	"github.com/google/uuid"
+	"goLangToDoApp/pkg/config"
+	"goLangToDoApp/pkg/core"
+	"io"
 	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
 )
 
 const TraceIDString = "trace_id"
-const DataFile = "../data/ToDoData.json"
+
+// LogOutput is where the default logger writes. Commands whose output is read
+// by scripts point it at os.Stderr before calling Init.
+var LogOutput io.Writer = os.Stdout
 
 type customHandler struct {
 	slog.Handler
 }
 
 func (h *customHandler) Handle(ctx context.Context, r slog.Record) error {
-	if traceID, ok := ctx.Value(TraceIDString).(string); ok {
+	if traceID := TraceID(ctx); traceID != "" {
 		r.AddAttrs(slog.String(TraceIDString, traceID))
 	}
-	if traceID, ok := ctx.Value(TraceIDString).(uuid.UUID); ok {
-		r.AddAttrs(slog.String(TraceIDString, traceID.String()))
-	}
 	return h.Handler.Handle(ctx, r)
 }
 
-func Init() context.Context {
-	// Set default logger
-	baseHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})
-	handler := &customHandler{baseHandler}
-	logger := slog.New(handler)
-	slog.SetDefault(logger)
-
-	ctx := context.WithValue(context.Background(), TraceIDString, uuid.New())
-	return ctx
+func (h *customHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &customHandler{h.Handler.WithAttrs(attrs)}
 }
 
-func Exit(ctx context.Context) {
-	// Signal channel listens for
-	signalChannel := make(chan os.Signal, 1)
-	signal.Notify(signalChannel, os.Interrupt, syscall.SIGINT)
+func (h *customHandler) WithGroup(name string) slog.Handler {
+	return &customHandler{h.Handler.WithGroup(name)}
+}
 
-	// System Exit go routine
-	go systemExit(ctx, signalChannel)
+// Init installs the default logger and returns a context with a new trace id
+// for the work done at startup. Requests get their own trace id from
+// TraceMiddleware.
+func Init() context.Context {
+	// Set default logger
+	SetLogger(slog.LevelInfo, config.LogText)
+	return WithTraceID(context.Background(), NewTraceID())
+}
 
-	// Infinite loop to keep the application running
-	slog.InfoContext(ctx, "Application is Running. Press Ctrl+C to exit.")
-	select {}
+// SetLogger installs the default logger writing records at level or above in
+// the given format, text or json.
+func SetLogger(level slog.Level, format string) {
+	options := &slog.HandlerOptions{Level: level}
+	var baseHandler slog.Handler = slog.NewTextHandler(LogOutput, options)
+	if format == config.LogJSON {
+		baseHandler = slog.NewJSONHandler(LogOutput, options)
+	}
+	slog.SetDefault(slog.New(&customHandler{baseHandler}))
 }
 
-// Basic system exit go routine
-func systemExit(ctx context.Context, signalChannel chan os.Signal) {
-	<-signalChannel
-	slog.InfoContext(ctx, "Received termination signal, shutting down...")
-	os.Exit(0)
+// Configure applies the log and workflow settings of cfg.
+func Configure(cfg config.Config) error {
+	level, err := cfg.Level()
+	if err != nil {
+		return err
+	}
+	SetLogger(level, cfg.LogFormat)
+
+	if len(cfg.Statuses) > 0 {
+		return core.SetWorkflow(core.Workflow{Statuses: cfg.Statuses})
+	}
+	return LoadWorkflow(cfg.WorkflowFile)
 }
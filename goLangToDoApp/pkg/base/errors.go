@@ -0,0 +1,33 @@
+package base
+
+import (
+	"errors"
+	"goLangToDoApp/pkg/auth"
+	"goLangToDoApp/pkg/core"
+	"net/http"
+)
+
+// ErrorStatus maps an error returned by a store to an HTTP status code and a
+// short machine readable error code.
+func ErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, core.ErrNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, core.ErrInvalidStatus):
+		return http.StatusBadRequest, "invalid_status"
+	case errors.Is(err, core.ErrValidation):
+		return http.StatusBadRequest, "validation_failed"
+	case errors.Is(err, auth.ErrUnauthorized):
+		return http.StatusUnauthorized, "unauthorized"
+	case errors.Is(err, auth.ErrForbidden):
+		return http.StatusForbidden, "forbidden"
+	case errors.Is(err, core.ErrConflict):
+		return http.StatusConflict, "conflict"
+	case errors.Is(err, core.ErrClosed), errors.Is(err, ErrUnavailable):
+		return http.StatusServiceUnavailable, "unavailable"
+	case errors.Is(err, core.ErrStorage):
+		return http.StatusInternalServerError, "storage_failure"
+	default:
+		return http.StatusInternalServerError, "internal_error"
+	}
+}
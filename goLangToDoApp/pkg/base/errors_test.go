@@ -0,0 +1,42 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/auth"
+	"goLangToDoApp/pkg/core"
+	"net/http"
+	"testing"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"NotFound", core.NotFoundError(3), http.StatusNotFound, "not_found"},
+		{"InvalidStatus", fmt.Errorf("%w: status %q", core.ErrInvalidStatus, "bogus"), http.StatusBadRequest, "invalid_status"},
+		{"Validation", fmt.Errorf("%w: missing description", core.ErrValidation), http.StatusBadRequest, "validation_failed"},
+		{"Conflict", core.ErrConflict, http.StatusConflict, "conflict"},
+		{"Transition", &core.TransitionError{ItemId: 1, From: "completed", To: "started"}, http.StatusConflict, "conflict"},
+		{"Unauthorized", auth.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+		{"Forbidden", auth.ErrForbidden, http.StatusForbidden, "forbidden"},
+		{"NothingToUndo", core.ErrNothingToUndo, http.StatusConflict, "conflict"},
+		{"Closed", core.ErrClosed, http.StatusServiceUnavailable, "unavailable"},
+		{"Unavailable", fmt.Errorf("%w: 2 lists are in use", ErrUnavailable), http.StatusServiceUnavailable, "unavailable"},
+		{"Storage", core.StorageError(errors.New("disk full")), http.StatusInternalServerError, "storage_failure"},
+		{"Wrapped", fmt.Errorf("request failed: %w", core.NotFoundError(3)), http.StatusNotFound, "not_found"},
+		{"Joined", errors.Join(errors.New("other"), core.ErrConflict), http.StatusConflict, "conflict"},
+		{"Unknown", errors.New("boom"), http.StatusInternalServerError, "internal_error"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			status, code := ErrorStatus(tc.err)
+			if status != tc.status || code != tc.code {
+				t.Errorf("Expected %d %s, got %d %s", tc.status, tc.code, status, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// EventsRefresh is the default refresh interval of Events.
+const EventsRefresh = 2 * time.Second
+
+// Events streams the changes of stores to HTTP clients as Server-Sent Events.
+type Events struct {
+	// Refresh is how often a streamed store is read, which lets stores that
+	// reload their data file report changes made by other processes. One
+	// poller reads each store for all of its streams, as the store publishes
+	// the changes to every subscriber. Zero or less disables it. Streams are
+	// kept alive with a comment at the same interval.
+	Refresh time.Duration
+
+	stop    chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	pollers map[core.Store]*poller
+}
+
+// poller reads a store every Refresh while it has streams.
+type poller struct {
+	streams int
+	cancel  context.CancelFunc
+}
+
+// NewEvents initializes Events with the given refresh interval.
+func NewEvents(refresh time.Duration) *Events {
+	return &Events{Refresh: refresh, stop: make(chan struct{}), pollers: make(map[core.Store]*poller)}
+}
+
+// Stop ends every stream. Register it with http.Server.RegisterOnShutdown,
+// as open streams would otherwise hold up the graceful shutdown.
+func (events *Events) Stop() {
+	events.once.Do(func() { close(events.stop) })
+}
+
+// Serve streams the changes of store until the client goes away, the store is
+// closed or Stop is called. Every change is an event named after its op, add,
+// update or delete, with the item as JSON data.
+func (events *Events) Serve(res http.ResponseWriter, req *http.Request, store core.Store) {
+	ctx := req.Context()
+	controller := http.NewResponseController(res)
+	changes := store.Subscribe(ctx)
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	_, err := fmt.Fprint(res, "retry: 3000\n\n")
+	if err == nil {
+		err = controller.Flush()
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to start event stream.", "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "Event stream opened.")
+	defer slog.InfoContext(ctx, "Event stream closed.")
+
+	var keepAlive <-chan time.Time
+	if events.Refresh > 0 {
+		defer events.poll(store)()
+		ticker := time.NewTicker(events.Refresh)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events.stop:
+			return
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(change.Item)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to encode To-Do Item.", "error", err)
+				continue
+			}
+			_, err = fmt.Fprintf(res, "event: %s\ndata: %s\n\n", change.Op, data)
+			if err == nil {
+				err = controller.Flush()
+			}
+			if err != nil {
+				return
+			}
+		case <-keepAlive:
+			_, err := fmt.Fprint(res, ": keep-alive\n\n")
+			if err == nil {
+				err = controller.Flush()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// poll starts reading store every Refresh unless one of its streams already
+// does, and returns the function ending the stream. The poller stops with
+// the last stream of the store.
+func (events *Events) poll(store core.Store) func() {
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	p, ok := events.pollers[store]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		p = &poller{cancel: cancel}
+		events.pollers[store] = p
+		go events.refresh(ctx, store)
+	}
+	p.streams++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			events.mu.Lock()
+			defer events.mu.Unlock()
+			p.streams--
+			if p.streams == 0 {
+				p.cancel()
+				delete(events.pollers, store)
+			}
+		})
+	}
+}
+
+// refresh reads store every Refresh until ctx is done, Stop is called or the
+// store is closed.
+func (events *Events) refresh(ctx context.Context, store core.Store) {
+	ticker := time.NewTicker(events.Refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events.stop:
+			return
+		case <-ticker.C:
+			_, err := store.GetAllToDoItems(ctx)
+			if errors.Is(err, core.ErrClosed) {
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				slog.WarnContext(ctx, "Failed to refresh To-Do Items.", "error", err)
+			}
+		}
+	}
+}
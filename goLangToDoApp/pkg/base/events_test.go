@@ -0,0 +1,116 @@
+package base
+
+import (
+	"bufio"
+	"context"
+	"goLangToDoApp/pkg/core"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEventsServe(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(StoreActor, core.FormatJSON, filepath.Join(t.TempDir(), "ToDoData.json"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	events := NewEvents(0)
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		events.Serve(res, req, store)
+	}))
+	t.Cleanup(server.Close)
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	defer res.Body.Close()
+	if contentType := res.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Unexpected content type %q", contentType)
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	next := func() string {
+		t.Helper()
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(time.Second):
+			t.Fatalf("Expected another line of the event stream")
+			return ""
+		}
+	}
+
+	if line := next(); line != "retry: 3000" {
+		t.Errorf("Expected the retry interval first, got %q", line)
+	}
+	next()
+	if err := store.AddNewToDoItem(ctx, "Task 1"); err != nil {
+		t.Fatalf("Failed to add To-Do Item: %v", err)
+	}
+	if line := next(); line != "event: add" {
+		t.Errorf("Expected an add event, got %q", line)
+	}
+	if line := next(); !strings.HasPrefix(line, "data: ") || !strings.Contains(line, `"description":"Task 1"`) {
+		t.Errorf("Expected the item as data, got %q", line)
+	}
+
+	events.Stop()
+	for range lines {
+	}
+}
+
+// countingStore counts the reads of a store.
+type countingStore struct {
+	core.Store
+	reads atomic.Int32
+}
+
+func (store *countingStore) GetAllToDoItems(ctx context.Context) ([]core.Item, error) {
+	store.reads.Add(1)
+	return store.Store.GetAllToDoItems(ctx)
+}
+
+func TestEventsSharedPoller(t *testing.T) {
+	ctx := context.Background()
+	actual, err := NewStore(StoreActor, core.FormatJSON, filepath.Join(t.TempDir(), "ToDoData.json"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { _ = actual.Close(ctx) })
+	store := &countingStore{Store: actual}
+
+	events := NewEvents(10 * time.Millisecond)
+	defer events.Stop()
+	endFirst, endSecond := events.poll(store), events.poll(store)
+	if len(events.pollers) != 1 {
+		t.Fatalf("Expected one poller for both streams, got %d", len(events.pollers))
+	}
+	time.Sleep(55 * time.Millisecond)
+	endFirst()
+	endFirst()
+	if len(events.pollers) != 1 {
+		t.Errorf("Expected the poller to keep running for the second stream")
+	}
+	endSecond()
+	if len(events.pollers) != 0 {
+		t.Errorf("Expected the poller to stop with the last stream")
+	}
+	if reads := store.reads.Load(); reads == 0 || reads > 7 {
+		t.Errorf("Expected about one read per refresh, got %d", reads)
+	}
+}
@@ -0,0 +1,135 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Shutdown stops an application cleanly when it receives SIGINT or SIGTERM.
+// Hooks run in the reverse order of registration, so a server registered
+// after its store stops serving before the store is closed.
+type Shutdown struct {
+	// Timeout bounds the whole shutdown, zero or less means no limit.
+	Timeout time.Duration
+
+	mu    sync.Mutex
+	hooks []shutdownHook
+	once  sync.Once
+	err   error
+}
+
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewShutdown initializes a Shutdown with the given timeout.
+func NewShutdown(timeout time.Duration) *Shutdown {
+	return &Shutdown{Timeout: timeout}
+}
+
+// OnShutdown registers a cleanup hook.
+func (shutdown *Shutdown) OnShutdown(name string, fn func(ctx context.Context) error) {
+	shutdown.mu.Lock()
+	defer shutdown.mu.Unlock()
+	shutdown.hooks = append(shutdown.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// AddServer registers server to stop accepting connections and drain its
+// in-flight requests. Connections still open at the timeout are closed.
+func (shutdown *Shutdown) AddServer(server *http.Server) {
+	shutdown.OnShutdown("http server "+server.Addr, func(ctx context.Context) error {
+		err := server.Shutdown(ctx)
+		if err != nil {
+			_ = server.Close()
+		}
+		return err
+	})
+}
+
+// AddStore registers store to be flushed and closed.
+func (shutdown *Shutdown) AddStore(store core.Store) {
+	shutdown.OnShutdown("store", store.Close)
+}
+
+// Serve runs server until the application receives SIGINT or SIGTERM, ctx is
+// done or the server fails, then runs the shutdown, which stops server first.
+func (shutdown *Shutdown) Serve(ctx context.Context, server *http.Server) error {
+	shutdown.AddServer(server)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		err := server.ListenAndServe()
+		if !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+			cancel()
+		}
+	}()
+
+	err := shutdown.Wait(ctx)
+	select {
+	case listenErr := <-serveErr:
+		return errors.Join(listenErr, err)
+	default:
+		return err
+	}
+}
+
+// Wait blocks until the application receives SIGINT or SIGTERM or ctx is
+// done, then runs the shutdown. A second signal kills the process.
+func (shutdown *Shutdown) Wait(ctx context.Context) error {
+	signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	<-signalCtx.Done()
+	stop()
+
+	slog.InfoContext(ctx, "Shutting down...")
+	return shutdown.Run(ctx)
+}
+
+// Exit runs the shutdown and exits the process with code. Commands call it
+// when they fail to start, so what they opened so far is still closed.
+func (shutdown *Shutdown) Exit(ctx context.Context, code int) {
+	_ = shutdown.Run(ctx)
+	os.Exit(code)
+}
+
+// Run executes the registered hooks once and returns their joined errors.
+// Later calls return the same result.
+func (shutdown *Shutdown) Run(ctx context.Context) error {
+	shutdown.once.Do(func() {
+		ctx := context.WithoutCancel(ctx)
+		if shutdown.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, shutdown.Timeout)
+			defer cancel()
+		}
+
+		shutdown.mu.Lock()
+		hooks := shutdown.hooks
+		shutdown.mu.Unlock()
+
+		var errs []error
+		for i := len(hooks) - 1; i >= 0; i-- {
+			err := hooks[i].fn(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Shutdown step failed.", "step", hooks[i].name, "error", err)
+				errs = append(errs, fmt.Errorf("%s: %w", hooks[i].name, err))
+				continue
+			}
+			slog.DebugContext(ctx, "Shutdown step done.", "step", hooks[i].name)
+		}
+		shutdown.err = errors.Join(errs...)
+	})
+	return shutdown.err
+}
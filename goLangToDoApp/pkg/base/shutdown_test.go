@@ -0,0 +1,100 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestShutdownRunsHooksInReverse(t *testing.T) {
+	shutdown := NewShutdown(time.Second)
+	var order []string
+	failure := errors.New("flush failed")
+	shutdown.OnShutdown("store", func(context.Context) error {
+		order = append(order, "store")
+		return failure
+	})
+	shutdown.OnShutdown("server", func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("Expected hook context with the shutdown timeout")
+		}
+		order = append(order, "server")
+		return nil
+	})
+
+	err := shutdown.Run(context.Background())
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected hook error to be returned, got %v", err)
+	}
+	if !slices.Equal(order, []string{"server", "store"}) {
+		t.Errorf("Expected hooks in reverse order, got %v", order)
+	}
+	if err := shutdown.Run(context.Background()); !errors.Is(err, failure) || len(order) != 2 {
+		t.Errorf("Expected hooks to run once, got %v (%v)", order, err)
+	}
+}
+
+func TestShutdownDrainsServer(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(res http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		res.WriteHeader(http.StatusNoContent)
+	})}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(listener) }()
+
+	shutdown := NewShutdown(5 * time.Second)
+	shutdown.AddServer(server)
+	closed := false
+	shutdown.OnShutdown("cleanup", func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	status := make(chan int, 1)
+	go func() {
+		res, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			status <- 0
+			return
+		}
+		_ = res.Body.Close()
+		status <- res.StatusCode
+	}()
+	<-started
+
+	done := make(chan error, 1)
+	go func() { done <- shutdown.Run(context.Background()) }()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+	if code := <-status; code != http.StatusNoContent {
+		t.Errorf("Expected in-flight request to complete, got status %d", code)
+	}
+	if !closed {
+		t.Errorf("Expected cleanup hook to run")
+	}
+}
+
+func TestShutdownServeStopsOnContext(t *testing.T) {
+	shutdown := NewShutdown(time.Second)
+	server := &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := shutdown.Serve(ctx, server); err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+}
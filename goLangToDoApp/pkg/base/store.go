@@ -0,0 +1,57 @@
+package base
+
+import (
+	"fmt"
+	"goLangToDoApp/pkg/config"
+	"goLangToDoApp/pkg/core"
+	"goLangToDoApp/pkg/todo"
+	"goLangToDoApp/pkg/todoCon"
+	"os"
+	"path/filepath"
+)
+
+const (
+	StorePlain = "plain"
+	StoreActor = "actor"
+)
+
+// NewStore opens the To-Do store implementation selected by kind, persisting
+// to filePath in the given storage format.
+func NewStore(kind string, format string, filePath string) (core.Store, error) {
+	backend, err := core.NewBackend(format, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case StorePlain:
+		return todo.NewToDoStoreWithBackend(backend)
+	case StoreActor:
+		return todoCon.NewToDoStoreWithBackend(backend)
+	default:
+		return nil, fmt.Errorf("unknown store %q, expected %q or %q", kind, StorePlain, StoreActor)
+	}
+}
+
+// OpenStore opens the To-Do store configured in cfg, creating the directory
+// of the data file when needed.
+func OpenStore(cfg config.Config) (core.Store, error) {
+	err := os.MkdirAll(filepath.Dir(cfg.DataFile), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("error creating data directory: %w", err)
+	}
+	return NewStore(cfg.Store, cfg.Format, cfg.DataFile)
+}
+
+// LoadWorkflow activates the workflow configured in filePath. An empty path
+// keeps the default workflow.
+func LoadWorkflow(filePath string) error {
+	if filePath == "" {
+		return nil
+	}
+	workflow, err := core.ReadWorkflow(filePath)
+	if err != nil {
+		return err
+	}
+	return core.SetWorkflow(workflow)
+}
@@ -0,0 +1,124 @@
+package base
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Headers used to propagate the trace id of a request.
+const (
+	TraceParentHeader = "traceparent"
+	RequestIDHeader   = "X-Request-ID"
+)
+
+// maxRequestIDLength bounds the X-Request-ID values adopted as trace id.
+const maxRequestIDLength = 128
+
+// NewTraceID returns a random trace id in the W3C trace context format, 32
+// lowercase hex characters.
+func NewTraceID() string {
+	id := uuid.New()
+	return hex.EncodeToString(id[:])
+}
+
+// WithTraceID returns a copy of ctx whose log records carry traceID.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, TraceIDString, traceID)
+}
+
+// TraceID returns the trace id stored in ctx, or an empty string.
+func TraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(TraceIDString).(string)
+	return traceID
+}
+
+// RequestTraceID returns the trace id of an incoming request: the trace id of
+// a valid traceparent header, else a well formed X-Request-ID, else a new one.
+func RequestTraceID(req *http.Request) string {
+	if traceID, ok := parseTraceParent(req.Header.Get(TraceParentHeader)); ok {
+		return traceID
+	}
+	if requestID := req.Header.Get(RequestIDHeader); validRequestID(requestID) {
+		return requestID
+	}
+	return NewTraceID()
+}
+
+// TraceParent returns a traceparent header value for traceID with a new
+// parent id, or an empty string when traceID is not a W3C trace id.
+func TraceParent(traceID string) string {
+	if !isHex(traceID, 32) {
+		return ""
+	}
+	var parentID [8]byte
+	_, _ = rand.Read(parentID[:])
+	return "00-" + traceID + "-" + hex.EncodeToString(parentID[:]) + "-01"
+}
+
+// TraceMiddleware gives every request its own trace id, adopted from the
+// request headers when present, stores it in the request context and echoes
+// it in the response headers.
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		traceID := RequestTraceID(req)
+		res.Header().Set(RequestIDHeader, traceID)
+		if traceParent := TraceParent(traceID); traceParent != "" {
+			res.Header().Set(TraceParentHeader, traceParent)
+		}
+
+		ctx := WithTraceID(req.Context(), traceID)
+		slog.DebugContext(ctx, "Handling request.", "method", req.Method, "path", req.URL.Path)
+		next.ServeHTTP(res, req.WithContext(ctx))
+	})
+}
+
+// parseTraceParent returns the trace id of a version 00 traceparent header,
+// "00-<trace id>-<parent id>-<flags>". All zero ids are invalid.
+func parseTraceParent(header string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return "", false
+	}
+	traceID, parentID, flags := parts[1], parts[2], parts[3]
+	if !isHex(traceID, 32) || !isHex(parentID, 16) || !isHex(flags, 2) {
+		return "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return "", false
+	}
+	return traceID, true
+}
+
+// validRequestID accepts short ids made of letters, digits, '-', '_' and '.',
+// which keeps client supplied values from corrupting the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package base
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"", "", false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "", false},
+		{"00-4bf92f3577b34da6-00f067aa0ba902b7-01", "", false},
+	}
+	for _, tc := range tests {
+		got, ok := parseTraceParent(tc.header)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("parseTraceParent(%q) = %q, %v, expected %q, %v", tc.header, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	var seen string
+	handler := TraceMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		seen = TraceID(req.Context())
+	}))
+	serve := func(header string, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/todo/get", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	res := serve(TraceParentHeader, "00-"+traceID+"-00f067aa0ba902b7-01")
+	if seen != traceID || res.Header().Get(RequestIDHeader) != traceID {
+		t.Errorf("Expected traceparent trace id %s to be adopted, got %q", traceID, seen)
+	}
+	if parent := res.Header().Get(TraceParentHeader); !strings.HasPrefix(parent, "00-"+traceID+"-") {
+		t.Errorf("Expected traceparent with trace id %s in response, got %q", traceID, parent)
+	}
+
+	res = serve(RequestIDHeader, "req-42")
+	if seen != "req-42" || res.Header().Get(RequestIDHeader) != "req-42" {
+		t.Errorf("Expected X-Request-ID to be adopted, got %q", seen)
+	}
+	if parent := res.Header().Get(TraceParentHeader); parent != "" {
+		t.Errorf("Expected no traceparent for a non W3C request id, got %q", parent)
+	}
+
+	res = serve(RequestIDHeader, "bad id\nforged=1")
+	first := seen
+	if !isHex(first, 32) || res.Header().Get(RequestIDHeader) != first {
+		t.Errorf("Expected a new trace id for an invalid X-Request-ID, got %q", first)
+	}
+	serve("", "")
+	if seen == first || !isHex(seen, 32) {
+		t.Errorf("Expected a fresh trace id per request, got %q twice", seen)
+	}
+}
@@ -0,0 +1,223 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/config"
+	"goLangToDoApp/pkg/core"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultUser is the user of requests without identity. Its list is the
+// configured data file, the list from before users existed.
+const DefaultUser = ""
+
+// UserHeader identifies the user a request is made for.
+const UserHeader = "X-User-ID"
+
+// maxUserLength bounds the length of user ids.
+const maxUserLength = 64
+
+// Default limits of the open stores of UserStores.
+const (
+	DefaultMaxOpen     = 100
+	DefaultIdleTimeout = time.Minute
+)
+
+// ErrUnavailable is returned when no store can be opened for another user, as
+// every open store is in use.
+var ErrUnavailable = errors.New("unavailable")
+
+// UserStores opens one isolated store per user. Every user's items are
+// persisted to their own data file below the users directory next to the
+// configured data file.
+type UserStores struct {
+	// MaxOpen bounds the open stores, as any request may name a new user when
+	// authentication is disabled. Opening another store closes the least
+	// recently used one that was idle for IdleTimeout, and fails when there is
+	// none. The default list, pinned stores and held stores are never closed.
+	// Zero or less leaves the stores unbounded.
+	MaxOpen     int
+	IdleTimeout time.Duration
+
+	cfg    config.Config
+	mu     sync.Mutex
+	stores map[string]*openStore
+	closed bool
+}
+
+// openStore is the store of a user and when it was last asked for or
+// released.
+type openStore struct {
+	user   string
+	store  core.Store
+	used   time.Time
+	pinned bool
+	// holds counts the holders which have not released the store yet.
+	holds int
+}
+
+// NewUserStores initializes UserStores opening stores configured by cfg, with
+// the default limits.
+func NewUserStores(cfg config.Config) *UserStores {
+	return &UserStores{
+		MaxOpen:     DefaultMaxOpen,
+		IdleTimeout: DefaultIdleTimeout,
+		cfg:         cfg,
+		stores:      make(map[string]*openStore),
+	}
+}
+
+// ValidateUser checks that user is usable as a user id, made of at most 64
+// letters, digits, '-', '_' and '.' and not a relative path.
+func ValidateUser(user string) error {
+	if len(user) > maxUserLength || user == "." || user == ".." || !validRequestID(user) {
+		return fmt.Errorf("%w: invalid user id %q", core.ErrValidation, user)
+	}
+	return nil
+}
+
+// UserDataFile returns the data file holding the items of user.
+func UserDataFile(dataFile string, user string) string {
+	if user == DefaultUser {
+		return dataFile
+	}
+	return filepath.Join(filepath.Dir(dataFile), "users", user, filepath.Base(dataFile))
+}
+
+// Store returns the store of user, opening it on first use.
+func (users *UserStores) Store(ctx context.Context, user string) (core.Store, error) {
+	open, err := users.open(ctx, user, func(*openStore) {})
+	if err != nil {
+		return nil, err
+	}
+	return open.store, nil
+}
+
+// Pin returns the store of user like Store and keeps it open until Close, for
+// watchers holding on to it.
+func (users *UserStores) Pin(ctx context.Context, user string) (core.Store, error) {
+	open, err := users.open(ctx, user, func(open *openStore) { open.pinned = true })
+	if err != nil {
+		return nil, err
+	}
+	return open.store, nil
+}
+
+// Hold returns the store of user like Store and keeps it open until release
+// is called, for event streams reading it for longer than a request.
+func (users *UserStores) Hold(ctx context.Context, user string) (store core.Store, release func(), err error) {
+	open, err := users.open(ctx, user, func(open *openStore) { open.holds++ })
+	if err != nil {
+		return nil, nil, err
+	}
+	var once sync.Once
+	return open.store, func() {
+		once.Do(func() {
+			users.mu.Lock()
+			defer users.mu.Unlock()
+			open.holds--
+			open.used = time.Now()
+		})
+	}, nil
+}
+
+// open returns the open store of user, opening it when needed, and applies
+// use to it under the lock. An idle store evicted to make room is closed
+// after unlocking, so a slow close does not stall every other user.
+func (users *UserStores) open(ctx context.Context, user string, use func(*openStore)) (*openStore, error) {
+	if user != DefaultUser {
+		err := ValidateUser(user)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	users.mu.Lock()
+	open, evicted, err := users.openLocked(ctx, user)
+	if err == nil {
+		use(open)
+	}
+	users.mu.Unlock()
+
+	if evicted != nil {
+		closeErr := evicted.store.Close(ctx)
+		if closeErr != nil {
+			slog.WarnContext(ctx, "Failed to close idle To-Do store.", "user", evicted.user, "error", closeErr)
+		} else {
+			slog.DebugContext(ctx, "Closed idle To-Do store.", "user", evicted.user)
+		}
+	}
+	return open, err
+}
+
+// openLocked returns the open store of user, opening it when needed, and the
+// store evicted to make room for it, if any. The caller holds mu and closes
+// the evicted store.
+func (users *UserStores) openLocked(ctx context.Context, user string) (open *openStore, evicted *openStore, err error) {
+	if users.closed {
+		return nil, nil, core.ErrClosed
+	}
+	if open, ok := users.stores[user]; ok {
+		open.used = time.Now()
+		return open, nil, nil
+	}
+	if users.MaxOpen > 0 && len(users.stores) >= users.MaxOpen {
+		evicted, err = users.evict()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	cfg := users.cfg
+	cfg.DataFile = UserDataFile(cfg.DataFile, user)
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return nil, evicted, core.StorageError(err)
+	}
+	open = &openStore{user: user, store: store, used: time.Now()}
+	users.stores[user] = open
+	slog.DebugContext(ctx, "Opened To-Do store.", "user", user, "file", cfg.DataFile)
+	return open, evicted, nil
+}
+
+// evict removes the least recently used store that may be closed, making
+// room for another one, and returns it for the caller to close. Stores that
+// are pinned, held or were used within IdleTimeout stay. The caller holds mu.
+func (users *UserStores) evict() (*openStore, error) {
+	var oldest *openStore
+	for user, open := range users.stores {
+		if user == DefaultUser || open.pinned || open.holds > 0 || time.Since(open.used) < users.IdleTimeout {
+			continue
+		}
+		if oldest == nil || open.used.Before(oldest.used) {
+			oldest = open
+		}
+	}
+	if oldest == nil {
+		return nil, fmt.Errorf("%w: %d lists are in use", ErrUnavailable, len(users.stores))
+	}
+	delete(users.stores, oldest.user)
+	return oldest, nil
+}
+
+// Close closes the stores of every user. Later calls to Store fail with
+// core.ErrClosed.
+func (users *UserStores) Close(ctx context.Context) error {
+	users.mu.Lock()
+	defer users.mu.Unlock()
+	users.closed = true
+
+	var errs []error
+	for user, open := range users.stores {
+		err := open.store.Close(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %q: %w", user, err))
+		}
+	}
+	return errors.Join(errs...)
+}
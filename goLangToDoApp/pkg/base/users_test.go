@@ -0,0 +1,148 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"goLangToDoApp/pkg/config"
+	"goLangToDoApp/pkg/core"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUserStoresIsolation(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Default()
+	cfg.Store = StoreActor
+	cfg.DataFile = filepath.Join(t.TempDir(), "ToDoData.json")
+	users := NewUserStores(cfg)
+	t.Cleanup(func() { _ = users.Close(ctx) })
+
+	for _, user := range []string{"alice", "bob", DefaultUser} {
+		store, err := users.Store(ctx, user)
+		if err != nil {
+			t.Fatalf("Failed to open store of %q: %v", user, err)
+		}
+		if err := store.AddNewToDoItem(ctx, "Task of "+user); err != nil {
+			t.Fatalf("Failed to Add New To-Do Item: %v", err)
+		}
+	}
+
+	for _, user := range []string{"alice", "bob", DefaultUser} {
+		store, err := users.Store(ctx, user)
+		if err != nil {
+			t.Fatalf("Failed to open store of %q: %v", user, err)
+		}
+		items, err := store.GetAllToDoItems(ctx)
+		if err != nil || len(items) != 1 || items[0].Description != "Task of "+user {
+			t.Errorf("Expected only the item of %q, got %v (%v)", user, items, err)
+		}
+	}
+
+	data, err := core.LoadData(UserDataFile(cfg.DataFile, "alice"))
+	if err != nil || len(data.Items) != 1 {
+		t.Errorf("Expected the items of alice in their own data file, got %v (%v)", data, err)
+	}
+}
+
+func TestUserStoresInvalidUser(t *testing.T) {
+	cfg := config.Default()
+	cfg.DataFile = filepath.Join(t.TempDir(), "ToDoData.json")
+	users := NewUserStores(cfg)
+
+	for _, user := range []string{"..", "../bob", "a/b", "bob smith"} {
+		if _, err := users.Store(context.Background(), user); !errors.Is(err, core.ErrValidation) {
+			t.Errorf("Expected ErrValidation for user %q, got %v", user, err)
+		}
+	}
+}
+
+func TestUserStoresClose(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Default()
+	cfg.DataFile = filepath.Join(t.TempDir(), "ToDoData.json")
+	users := NewUserStores(cfg)
+	store, err := users.Store(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	if err := users.Close(ctx); err != nil {
+		t.Fatalf("Failed to close stores: %v", err)
+	}
+	if _, err := store.GetAllToDoItems(ctx); !errors.Is(err, core.ErrClosed) {
+		t.Errorf("Expected open stores to be closed, got %v", err)
+	}
+	if _, err := users.Store(ctx, "bob"); !errors.Is(err, core.ErrClosed) {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestUserStoresEviction(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Default()
+	cfg.Store = StoreActor
+	cfg.DataFile = filepath.Join(t.TempDir(), "ToDoData.json")
+	users := NewUserStores(cfg)
+	users.MaxOpen = 3
+	users.IdleTimeout = 0
+	t.Cleanup(func() { _ = users.Close(ctx) })
+
+	open := func(user string) core.Store {
+		t.Helper()
+		store, err := users.Store(ctx, user)
+		if err != nil {
+			t.Fatalf("Failed to open store of %q: %v", user, err)
+		}
+		return store
+	}
+	open(DefaultUser)
+	if _, err := users.Pin(ctx, "hooked"); err != nil {
+		t.Fatalf("Failed to pin store: %v", err)
+	}
+	alice := open("alice")
+	if err := alice.AddNewToDoItem(ctx, "Task of alice"); err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+
+	// Only alice's store may make room for bob's.
+	open("bob")
+	if _, err := alice.GetAllToDoItems(ctx); !errors.Is(err, core.ErrClosed) {
+		t.Errorf("Expected the idle store of alice to be closed, got %v", err)
+	}
+	if len(users.stores) != 3 {
+		t.Errorf("Expected 3 open stores, got %d", len(users.stores))
+	}
+
+	// alice's list is reopened from her data file, closing bob's store.
+	items, err := open("alice").GetAllToDoItems(ctx)
+	if err != nil || len(items) != 1 {
+		t.Errorf("Expected the item of alice after reopening, got %v (%v)", items, err)
+	}
+	for _, user := range []string{DefaultUser, "hooked", "alice"} {
+		if _, ok := users.stores[user]; !ok {
+			t.Errorf("Expected the store of %q to stay open", user)
+		}
+	}
+
+	// Stores held by a stream are not closed until released.
+	_, release, err := users.Hold(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Failed to hold store: %v", err)
+	}
+	if _, err := users.Store(ctx, "carol"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable with the idle store held, got %v", err)
+	}
+	release()
+	release()
+	open("carol")
+	if _, ok := users.stores["alice"]; ok {
+		t.Errorf("Expected the released store of alice to be closed")
+	}
+
+	// Stores in use are not closed for new users.
+	users.IdleTimeout = time.Hour
+	if _, err := users.Store(ctx, "dave"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable with every store in use, got %v", err)
+	}
+}
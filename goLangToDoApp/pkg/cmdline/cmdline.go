@@ -0,0 +1,135 @@
+// Package cmdline parses the command lines of the REPL: words are split like
+// a shell does, with quotes and backslash escapes, then matched against the
+// positional and key=value arguments of the command.
+package cmdline
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrUsage reports a command line that does not match the command.
+var ErrUsage = errors.New("invalid usage")
+
+// Split splits line into words at unquoted spaces and tabs. Single quotes keep
+// their content as is, double quotes allow the escapes \" and \\, and a
+// backslash outside quotes keeps the next character as is. Quotes in the
+// middle of a word join it, so status="in progress" is one word.
+func Split(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch r {
+		case ' ', '\t', '\n', '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case '\\':
+			i++
+			if i == len(runes) {
+				return nil, fmt.Errorf("%w: trailing backslash", ErrUsage)
+			}
+			word.WriteRune(runes[i])
+			inWord = true
+		case '\'', '"':
+			end := i + 1
+			for ; end < len(runes) && runes[end] != r; end++ {
+				if r == '"' && runes[end] == '\\' && end+1 < len(runes) &&
+					(runes[end+1] == '"' || runes[end+1] == '\\') {
+					end++
+				}
+				word.WriteRune(runes[end])
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("%w: unterminated %c quote", ErrUsage, r)
+			}
+			i = end
+			inWord = true
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// Spec describes the arguments of a command.
+type Spec struct {
+	// Positional names the positional arguments in order, of which the first
+	// Required must be given.
+	Positional []string
+	Required   int
+	// Rest makes the last positional argument take the remaining words,
+	// joined by spaces.
+	Rest bool
+	// Named lists the keys of the accepted key=value arguments. A named
+	// argument may also be given positionally when it has the same name.
+	Named []string
+}
+
+// Args are the arguments of a command line by name.
+type Args map[string]string
+
+// Has reports whether the argument name was given.
+func (args Args) Has(name string) bool {
+	_, ok := args[name]
+	return ok
+}
+
+// EndOfNamed ends the named arguments, the words following it are positional
+// even when they look like key=value.
+const EndOfNamed = "--"
+
+// Parse matches the words following a command against spec. Words of the form
+// key=value with a key in spec.Named are named arguments, the others are
+// positional, as are all words after EndOfNamed.
+func (spec Spec) Parse(words []string) (Args, error) {
+	args := Args{}
+	var positional []string
+	for i, word := range words {
+		if word == EndOfNamed {
+			positional = append(positional, words[i+1:]...)
+			break
+		}
+		key, value, found := strings.Cut(word, "=")
+		if !found || !slices.Contains(spec.Named, key) {
+			positional = append(positional, word)
+			continue
+		}
+		if args.Has(key) {
+			return nil, fmt.Errorf("%w: %s given twice", ErrUsage, key)
+		}
+		args[key] = value
+	}
+
+	for _, name := range spec.Positional[min(len(positional), spec.Required):spec.Required] {
+		if !args.Has(name) {
+			return nil, fmt.Errorf("%w: missing %s", ErrUsage, name)
+		}
+	}
+	if len(positional) > len(spec.Positional) {
+		if !spec.Rest || len(spec.Positional) == 0 {
+			return nil, fmt.Errorf("%w: unexpected argument %q", ErrUsage, positional[len(spec.Positional)])
+		}
+		last := len(spec.Positional) - 1
+		positional = append(positional[:last], strings.Join(positional[last:], " "))
+	}
+	for i, value := range positional {
+		name := spec.Positional[i]
+		if args.Has(name) {
+			return nil, fmt.Errorf("%w: %s given twice", ErrUsage, name)
+		}
+		args[name] = value
+	}
+	return args, nil
+}
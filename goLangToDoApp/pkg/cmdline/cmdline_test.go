@@ -0,0 +1,84 @@
+package cmdline
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"  list   status=started ", []string{"list", "status=started"}},
+		{`add "buy  milk"`, []string{"add", "buy  milk"}},
+		{`add 'it''s' "say \"hi\" \\ \n"`, []string{"add", "its", `say "hi" \ \n`}},
+		{`update 3 desc="new text"`, []string{"update", "3", "desc=new text"}},
+		{`add don\'t\ stop`, []string{"add", "don't stop"}},
+		{`add '' "" x`, []string{"add", "", "", "x"}},
+		{"add\ttabbed", []string{"add", "tabbed"}},
+		{`add 'a "b"'`, []string{"add", `a "b"`}},
+	}
+	for _, test := range tests {
+		words, err := Split(test.line)
+		if err != nil || !slices.Equal(words, test.want) {
+			t.Errorf("Expected %q for %q, got %q, error %v", test.want, test.line, words, err)
+		}
+	}
+
+	for _, line := range []string{`add "milk`, `add 'milk`, `add milk\`} {
+		if _, err := Split(line); !errors.Is(err, ErrUsage) {
+			t.Errorf("Expected ErrUsage for %q, got %v", line, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	update := Spec{
+		Positional: []string{"id", "status", "desc"},
+		Required:   1,
+		Rest:       true,
+		Named:      []string{"status", "desc", "priority"},
+	}
+	tests := []struct {
+		spec  Spec
+		words []string
+		want  Args
+	}{
+		{update, []string{"3"}, Args{"id": "3"}},
+		{update, []string{"3", "completed"}, Args{"id": "3", "status": "completed"}},
+		{update, []string{"3", "started", "new", "text"}, Args{"id": "3", "status": "started", "desc": "new text"}},
+		{update, []string{"3", "desc=new text", "priority="}, Args{"id": "3", "desc": "new text", "priority": ""}},
+		{update, []string{"status=started", "3"}, Args{"id": "3", "status": "started"}},
+		{Spec{Positional: []string{"desc"}, Rest: true}, []string{"a=b", "c"}, Args{"desc": "a=b c"}},
+		{update, []string{"3", "priority=high", "--", "status=done", "x"}, Args{"id": "3", "priority": "high", "status": "status=done", "desc": "x"}},
+		{update, []string{"3", "--"}, Args{"id": "3"}},
+		{Spec{Positional: []string{"desc"}, Required: 1, Named: []string{"desc"}}, []string{"desc=a=b"}, Args{"desc": "a=b"}},
+		{Spec{}, nil, Args{}},
+	}
+	for _, test := range tests {
+		args, err := test.spec.Parse(test.words)
+		if err != nil || !maps.Equal(args, test.want) {
+			t.Errorf("Expected %v for %q, got %v, error %v", test.want, test.words, args, err)
+		}
+	}
+
+	failures := []struct {
+		spec  Spec
+		words []string
+	}{
+		{update, nil},
+		{update, []string{"3", "started", "status=completed"}},
+		{update, []string{"3", "priority=low", "priority=high"}},
+		{Spec{Positional: []string{"id"}, Required: 1}, []string{"3", "4"}},
+		{Spec{}, []string{"x"}},
+	}
+	for _, test := range failures {
+		if _, err := test.spec.Parse(test.words); !errors.Is(err, ErrUsage) {
+			t.Errorf("Expected ErrUsage for %q, got %v", test.words, err)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+// Package config loads the settings shared by every command. Each setting is
+// layered, later sources overriding earlier ones: defaults, a JSON config
+// file, TODO_* environment variables and command-line flags.
+//
+// The default config and data files live in the user config directory, see
+// AppDir. The commands used to keep their data in cmd/data, run from their own
+// directory. That data file is still used while the user config directory has
+// none, but the sample cmd/data/config.json is only read when given with
+// -config or TODO_CONFIG.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// minSecretLength is the minimum length of the JWT secret.
+const minSecretLength = 32
+
+// AppDir is the directory below the user config directory holding the
+// default config and data files.
+const AppDir = "goLangToDoApp"
+
+// legacyDataFile is where the commands kept the data file before it moved to
+// AppDir, relative to the command directory they were run from.
+var legacyDataFile = filepath.Join("..", "data", "ToDoData.json")
+
+const (
+	LogText = "text"
+	LogJSON = "json"
+)
+
+const (
+	AuthRequired = "required"
+	AuthDisabled = "disabled"
+)
+
+// Config holds the settings of a command.
+type Config struct {
+	// DataFile is the path of the To-Do data file.
+	DataFile string `json:"data_file"`
+	// Store is the store implementation, plain or actor.
+	Store string `json:"store"`
+	// Format is the storage format of the data file, json or log.
+	Format string `json:"format"`
+	// WorkflowFile configures statuses and transitions, see core.ReadWorkflow.
+	WorkflowFile string `json:"workflow_file,omitempty"`
+	// Statuses replaces the status set without transition rules. It cannot
+	// be combined with WorkflowFile.
+	Statuses []string `json:"statuses,omitempty"`
+	// APIAddr and WebAddr are the listen addresses of the API and web server.
+	APIAddr string `json:"api_addr"`
+	WebAddr string `json:"web_addr"`
+	// LogLevel is debug, info, warn or error. LogFormat is text or json.
+	LogLevel  string `json:"log_level"`
+	LogFormat string `json:"log_format"`
+	// ShutdownTimeout bounds the graceful shutdown of the servers.
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+	// Auth is required or disabled. When required, the servers only accept
+	// requests with an API token from TokensFile or a JWT signed with
+	// JWTSecret.
+	Auth       string `json:"auth"`
+	TokensFile string `json:"tokens_file"`
+	JWTSecret  string `json:"jwt_secret,omitempty"`
+	// WebhooksFile holds the webhooks registered with the API server, their
+	// recent deliveries are logged next to it.
+	WebhooksFile string `json:"webhooks_file"`
+	// HistoryFile keeps the lines entered in the REPL across sessions.
+	HistoryFile string `json:"history_file"`
+	// TemplateDir makes the web server read its templates from this
+	// directory on every request instead of the embedded copies, so edits
+	// show without a restart. Meant for development.
+	TemplateDir string `json:"template_dir,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "10s" in the config
+// file.
+type Duration time.Duration
+
+func (duration Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(duration).String())
+}
+
+func (duration *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return fmt.Errorf("duration must be a string such as \"10s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*duration = Duration(parsed)
+	return nil
+}
+
+// Default returns the default settings. The data file lives in the user
+// config directory so the commands work from any working directory.
+func Default() Config {
+	return Config{
+		DataFile:        defaultDataFile(),
+		Store:           "plain",
+		Format:          core.FormatJSON,
+		APIAddr:         ":8080",
+		WebAddr:         ":8081",
+		LogLevel:        "info",
+		LogFormat:       LogText,
+		ShutdownTimeout: Duration(10 * time.Second),
+		Auth:            AuthRequired,
+		TokensFile:      filepath.Join(appDir(), "tokens.json"),
+		WebhooksFile:    filepath.Join(appDir(), "webhooks.json"),
+		HistoryFile:     filepath.Join(appDir(), "history"),
+	}
+}
+
+// DefaultFile returns the config file read when none is given.
+func DefaultFile() string {
+	return filepath.Join(appDir(), "config.json")
+}
+
+// defaultDataFile returns the data file in the user config directory, or the
+// legacy data file while only that one exists, so existing lists are not lost
+// on upgrade.
+func defaultDataFile() string {
+	dataFile := filepath.Join(appDir(), "ToDoData.json")
+	if _, err := os.Stat(dataFile); !errors.Is(err, os.ErrNotExist) {
+		return dataFile
+	}
+	if _, err := os.Stat(legacyDataFile); err != nil {
+		return dataFile
+	}
+	slog.Warn("Using the legacy data file, move it to the default location to keep using it from any directory.",
+		"file", legacyDataFile, "default", dataFile)
+	return legacyDataFile
+}
+
+func appDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(dir, AppDir)
+}
+
+// setting describes how a Config field is set from the environment and flags.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	set   func(cfg *Config, value string) error
+}
+
+func setString(field func(cfg *Config) *string) func(cfg *Config, value string) error {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+var settings = []setting{
+	{"data", "TODO_DATA_FILE", "Path of the To-Do data file",
+		setString(func(cfg *Config) *string { return &cfg.DataFile })},
+	{"store", "TODO_STORE", "To-Do store implementation (plain or actor)",
+		setString(func(cfg *Config) *string { return &cfg.Store })},
+	{"format", "TODO_FORMAT", "Storage format of the data file (json or log)",
+		setString(func(cfg *Config) *string { return &cfg.Format })},
+	{"workflow", "TODO_WORKFLOW_FILE", "JSON file configuring the statuses and allowed transitions",
+		setString(func(cfg *Config) *string { return &cfg.WorkflowFile })},
+	{"statuses", "TODO_STATUSES", "Comma separated statuses, the first is given to new items",
+		func(cfg *Config, value string) error {
+			cfg.Statuses = nil
+			for _, status := range strings.Split(value, ",") {
+				if status = strings.TrimSpace(status); status != "" {
+					cfg.Statuses = append(cfg.Statuses, status)
+				}
+			}
+			return nil
+		}},
+	{"api-addr", "TODO_API_ADDR", "Listen address of the API server",
+		setString(func(cfg *Config) *string { return &cfg.APIAddr })},
+	{"web-addr", "TODO_WEB_ADDR", "Listen address of the web server",
+		setString(func(cfg *Config) *string { return &cfg.WebAddr })},
+	{"log-level", "TODO_LOG_LEVEL", "Minimum log level (debug, info, warn or error)",
+		setString(func(cfg *Config) *string { return &cfg.LogLevel })},
+	{"log-format", "TODO_LOG_FORMAT", "Log format (text or json)",
+		setString(func(cfg *Config) *string { return &cfg.LogFormat })},
+	{"shutdown-timeout", "TODO_SHUTDOWN_TIMEOUT", "Time allowed for in-flight requests to finish on shutdown",
+		func(cfg *Config, value string) error {
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			cfg.ShutdownTimeout = Duration(timeout)
+			return nil
+		}},
+	{"auth", "TODO_AUTH", "Authentication of server requests (required or disabled)",
+		setString(func(cfg *Config) *string { return &cfg.Auth })},
+	{"tokens", "TODO_TOKENS_FILE", "Path of the API token file",
+		setString(func(cfg *Config) *string { return &cfg.TokensFile })},
+	{"jwt-secret", "TODO_JWT_SECRET", "Secret verifying HS256 JWTs, at least 32 bytes",
+		setString(func(cfg *Config) *string { return &cfg.JWTSecret })},
+	{"webhooks", "TODO_WEBHOOKS_FILE", "Path of the webhook file",
+		setString(func(cfg *Config) *string { return &cfg.WebhooksFile })},
+	{"history", "TODO_HISTORY_FILE", "Path of the REPL history file",
+		setString(func(cfg *Config) *string { return &cfg.HistoryFile })},
+	{"templates", "TODO_TEMPLATE_DIR", "Directory the web server reloads its templates from, for development",
+		setString(func(cfg *Config) *string { return &cfg.TemplateDir })},
+}
+
+// Load parses args with fs, after adding the config flags to it, and returns
+// defaults overridden by the config file, the environment and the flags given
+// in args. The config file is the -config flag, else TODO_CONFIG, else
+// DefaultFile when it exists.
+func Load(fs *flag.FlagSet, args []string, defaults Config) (Config, error) {
+	configFile, values := addFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := defaults
+	path, required := *configFile, true
+	if path == "" {
+		path = os.Getenv("TODO_CONFIG")
+	}
+	if path == "" {
+		path, required = DefaultFile(), false
+	}
+	err = cfg.readFile(path, required)
+	if err != nil {
+		return Config{}, err
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			err := s.set(&cfg, value)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid %s: %w", s.env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && flagErr == nil {
+				if err := s.set(&cfg, *values[s.flag]); err != nil {
+					flagErr = fmt.Errorf("invalid -%s: %w", s.flag, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return Config{}, flagErr
+	}
+	return cfg, cfg.Validate()
+}
+
+// PrintDefaults writes the usage of the flags added by Load to w.
+func PrintDefaults(w io.Writer) {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(w)
+	addFlags(fs)
+	fs.PrintDefaults()
+}
+
+// addFlags adds the -config flag and a flag for every setting to fs.
+func addFlags(fs *flag.FlagSet) (*string, map[string]*string) {
+	configFile := fs.String("config", "", "JSON config file (env TODO_CONFIG, default "+DefaultFile()+")")
+	values := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		values[s.flag] = fs.String(s.flag, "", s.usage+" (env "+s.env+")")
+	}
+	return configFile, values
+}
+
+// readFile overrides cfg with the settings in the JSON file at path. Relative
+// paths in the file are resolved against the directory of the file.
+func (cfg *Config) readFile(path string, required bool) error {
+	byteValue, err := os.ReadFile(path)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+
+	var fileCfg Config
+	decoder := json.NewDecoder(bytes.NewReader(byteValue))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&fileCfg)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling config file %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(file string) string {
+		if file == "" || filepath.IsAbs(file) {
+			return file
+		}
+		return filepath.Join(dir, file)
+	}
+	cfg.merge(Config{
+		DataFile:        resolve(fileCfg.DataFile),
+		Store:           fileCfg.Store,
+		Format:          fileCfg.Format,
+		WorkflowFile:    resolve(fileCfg.WorkflowFile),
+		Statuses:        fileCfg.Statuses,
+		APIAddr:         fileCfg.APIAddr,
+		WebAddr:         fileCfg.WebAddr,
+		LogLevel:        fileCfg.LogLevel,
+		LogFormat:       fileCfg.LogFormat,
+		ShutdownTimeout: fileCfg.ShutdownTimeout,
+		Auth:            fileCfg.Auth,
+		TokensFile:      resolve(fileCfg.TokensFile),
+		JWTSecret:       fileCfg.JWTSecret,
+		WebhooksFile:    resolve(fileCfg.WebhooksFile),
+		HistoryFile:     resolve(fileCfg.HistoryFile),
+		TemplateDir:     resolve(fileCfg.TemplateDir),
+	})
+	return nil
+}
+
+// merge overrides cfg with the non-zero settings of other.
+func (cfg *Config) merge(other Config) {
+	for _, field := range []struct{ dst, src *string }{
+		{&cfg.DataFile, &other.DataFile},
+		{&cfg.Store, &other.Store},
+		{&cfg.Format, &other.Format},
+		{&cfg.WorkflowFile, &other.WorkflowFile},
+		{&cfg.APIAddr, &other.APIAddr},
+		{&cfg.WebAddr, &other.WebAddr},
+		{&cfg.LogLevel, &other.LogLevel},
+		{&cfg.LogFormat, &other.LogFormat},
+		{&cfg.Auth, &other.Auth},
+		{&cfg.TokensFile, &other.TokensFile},
+		{&cfg.JWTSecret, &other.JWTSecret},
+		{&cfg.WebhooksFile, &other.WebhooksFile},
+		{&cfg.HistoryFile, &other.HistoryFile},
+		{&cfg.TemplateDir, &other.TemplateDir},
+	} {
+		if *field.src != "" {
+			*field.dst = *field.src
+		}
+	}
+	if len(other.Statuses) > 0 {
+		cfg.Statuses = slices.Clone(other.Statuses)
+	}
+	if other.ShutdownTimeout != 0 {
+		cfg.ShutdownTimeout = other.ShutdownTimeout
+	}
+}
+
+// Validate checks the settings which are not validated where they are used.
+func (cfg Config) Validate() error {
+	if cfg.DataFile == "" {
+		return errors.New("data file must be set")
+	}
+	_, err := cfg.Level()
+	if err != nil {
+		return err
+	}
+	if cfg.LogFormat != LogText && cfg.LogFormat != LogJSON {
+		return fmt.Errorf("unknown log format %q, expected %q or %q", cfg.LogFormat, LogText, LogJSON)
+	}
+	if cfg.WorkflowFile != "" && len(cfg.Statuses) > 0 {
+		return errors.New("statuses cannot be combined with a workflow file, list them in the workflow file")
+	}
+	if cfg.ShutdownTimeout < 0 {
+		return errors.New("shutdown timeout must not be negative")
+	}
+	if cfg.Auth != AuthRequired && cfg.Auth != AuthDisabled {
+		return fmt.Errorf("unknown auth mode %q, expected %q or %q", cfg.Auth, AuthRequired, AuthDisabled)
+	}
+	if cfg.JWTSecret != "" && len(cfg.JWTSecret) < minSecretLength {
+		return fmt.Errorf("JWT secret must be at least %d bytes", minSecretLength)
+	}
+	return nil
+}
+
+// Level returns the parsed log level.
+func (cfg Config) Level() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(cfg.LogLevel))
+	if err != nil {
+		return 0, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", cfg.LogLevel)
+	}
+	return level, nil
+}
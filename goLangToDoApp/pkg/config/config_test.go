@@ -0,0 +1,128 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func load(t *testing.T, args ...string) (Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	return Load(fs, args, Default())
+}
+
+func writeConfig(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg, err := load(t)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !filepath.IsAbs(cfg.DataFile) || cfg.APIAddr != ":8080" || cfg.WebAddr != ":8081" {
+		t.Errorf("Unexpected defaults %+v", cfg)
+	}
+}
+
+func TestDefaultLegacyDataFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	legacy := filepath.Join(t.TempDir(), "ToDoData.json")
+	saved := legacyDataFile
+	legacyDataFile = legacy
+	t.Cleanup(func() { legacyDataFile = saved })
+
+	if cfg := Default(); cfg.DataFile == legacy {
+		t.Errorf("Expected the legacy data file only while it exists")
+	}
+	writeConfig(t, legacy, "{}")
+	if cfg := Default(); cfg.DataFile != legacy {
+		t.Errorf("Expected the existing legacy data file, got %s", cfg.DataFile)
+	}
+
+	// Once moved, the data file in the user config directory is used.
+	dataFile := filepath.Join(dir, AppDir, "ToDoData.json")
+	writeConfig(t, dataFile, "{}")
+	if cfg := Default(); cfg.DataFile != dataFile {
+		t.Errorf("Expected %s, got %s", dataFile, cfg.DataFile)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	writeConfig(t, DefaultFile(), `{
+		"data_file": "data/ToDoData.json",
+		"template_dir": "templates",
+		"api_addr": ":9000",
+		"web_addr": ":9001",
+		"log_level": "debug",
+		"shutdown_timeout": "3s"
+	}`)
+	t.Setenv("TODO_API_ADDR", ":9100")
+	t.Setenv("TODO_LOG_FORMAT", "json")
+
+	cfg, err := load(t, "-log-format", "text", "-statuses", "todo, doing,done")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.DataFile != filepath.Join(dir, AppDir, "data", "ToDoData.json") {
+		t.Errorf("Expected data file relative to the config file, got %s", cfg.DataFile)
+	}
+	if cfg.TemplateDir != filepath.Join(dir, AppDir, "templates") {
+		t.Errorf("Expected template dir relative to the config file, got %s", cfg.TemplateDir)
+	}
+	if cfg.WebAddr != ":9001" || cfg.LogLevel != "debug" || time.Duration(cfg.ShutdownTimeout) != 3*time.Second {
+		t.Errorf("Expected settings from the config file, got %+v", cfg)
+	}
+	if cfg.APIAddr != ":9100" {
+		t.Errorf("Expected environment to override the config file, got %s", cfg.APIAddr)
+	}
+	if cfg.LogFormat != LogText {
+		t.Errorf("Expected flag to override the environment, got %s", cfg.LogFormat)
+	}
+	if !slices.Equal(cfg.Statuses, []string{"todo", "doing", "done"}) {
+		t.Errorf("Unexpected statuses %v", cfg.Statuses)
+	}
+}
+
+func TestLoadExplicitFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if _, err := load(t, "-config", filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("Expected error for a missing config file given explicitly")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfig(t, path, `{"api_adr": ":9000"}`)
+	t.Setenv("TODO_CONFIG", path)
+	if _, err := load(t); err == nil {
+		t.Errorf("Expected error for an unknown config setting")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tests := [][]string{
+		{"-log-level", "verbose"},
+		{"-log-format", "xml"},
+		{"-workflow", "workflow.json", "-statuses", "a,b"},
+		{"-shutdown-timeout", "soon"},
+	}
+	for _, args := range tests {
+		if _, err := load(t, args...); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package core
+
+import "fmt"
+
+const (
+	FormatJSON = "json"
+	FormatLog  = "log"
+)
+
+const (
+	OpAdd    = "add"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Change describes a single mutation of the To-Do list.
+type Change struct {
+	Op   string `json:"op"`
+	Item Item   `json:"item"`
+	// Before is the item an update replaced, kept so the update can be undone.
+	Before *Item `json:"before,omitempty"`
+	// Journal is JournalUndo or JournalRedo when the change undoes or redoes
+	// an earlier one, see Journal.
+	Journal string `json:"journal,omitempty"`
+}
+
+// Backend persists the To-Do data of a store.
+type Backend interface {
+	// Load returns the persisted To-Do items and id sequence.
+	Load() (Data, error)
+	// Save persists the change which produced data. It fails with
+	// ErrConflict when another writer has changed the data since it was
+	// loaded, the store then has to load it again.
+	Save(data Data, change Change) error
+	// Flush persists data in full so nothing is left to replay on the next
+	// Load. Stores call it when they are closed.
+	Flush(data Data) error
+}
+
+// LockSuffix is appended to the data file name to get the file locked while
+// the data is written, so writers in several processes take turns.
+const LockSuffix = ".lock"
+
+// NewBackend returns the storage backend for the given file format.
+func NewBackend(format string, filePath string) (Backend, error) {
+	switch format {
+	case FormatJSON:
+		return NewJSONBackend(filePath), nil
+	case FormatLog:
+		return NewLogBackend(filePath), nil
+	default:
+		return nil, fmt.Errorf("unknown storage format %q, expected %q or %q", format, FormatJSON, FormatLog)
+	}
+}
+
+// changedError returns the error for a data file written by another store or
+// process since the backend last read it.
+func changedError(filePath string) error {
+	return fmt.Errorf("%w: %s was changed by another writer, reload and retry", ErrConflict, filePath)
+}
+
+// Apply returns items with the change applied. Applying the same change twice
+// gives the same result, which keeps log replay safe after a compaction.
+func (change Change) Apply(items []Item) []Item {
+	for index, item := range items {
+		if item.ItemId == change.Item.ItemId {
+			if change.Op == OpDelete {
+				return append(items[:index], items[index+1:]...)
+			}
+			items[index] = change.Item
+			return items
+		}
+	}
+	if change.Op == OpDelete {
+		return items
+	}
+	return append(items, change.Item)
+}
+
+// JSONBackend stores all To-Do items as a single JSON array which is rewritten
+// on every change.
+type JSONBackend struct {
+	filePath string
+	// nextId is the id sequence of the file as last loaded or saved, zero
+	// before the first Load.
+	nextId int
+}
+
+// NewJSONBackend initializes a JSONBackend for filePath.
+func NewJSONBackend(filePath string) *JSONBackend {
+	return &JSONBackend{filePath: filePath}
+}
+
+func (backend *JSONBackend) Load() (Data, error) {
+	data, err := LoadData(backend.filePath)
+	if err != nil {
+		return Data{}, err
+	}
+	backend.nextId = data.NextId
+	return data, nil
+}
+
+// Save fails with ErrConflict when another writer has added items to the file
+// since it was loaded, as the ids of data may then be taken already.
+func (backend *JSONBackend) Save(data Data, _ Change) error {
+	unlock, err := lockFile(backend.filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if backend.nextId > 0 {
+		current, err := LoadData(backend.filePath)
+		if err != nil {
+			return err
+		}
+		if current.NextId != backend.nextId {
+			return changedError(backend.filePath)
+		}
+	}
+	err = SaveData(backend.filePath, data)
+	if err != nil {
+		return err
+	}
+	backend.nextId = data.NextId
+	return nil
+}
+
+// Flush does nothing, every change has already been written in full.
+func (backend *JSONBackend) Flush(_ Data) error {
+	return nil
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// SubscriberBuffer is the number of changes buffered for each subscriber. A
+// subscriber falling further behind is dropped, so a slow reader never blocks
+// the store.
+const SubscriberBuffer = 64
+
+// Changes fans the changes of a store out to its subscribers. The zero value
+// is ready to use.
+type Changes struct {
+	mu          sync.Mutex
+	subscribers map[chan Change]struct{}
+	closed      bool
+}
+
+// Subscribe returns a channel receiving every change published after the
+// call. The channel is closed when ctx is done, when Close is called or when
+// the subscriber falls more than SubscriberBuffer changes behind.
+func (changes *Changes) Subscribe(ctx context.Context) <-chan Change {
+	ch := make(chan Change, SubscriberBuffer)
+	changes.mu.Lock()
+	defer changes.mu.Unlock()
+	if changes.closed {
+		close(ch)
+		return ch
+	}
+	if changes.subscribers == nil {
+		changes.subscribers = make(map[chan Change]struct{})
+	}
+	changes.subscribers[ch] = struct{}{}
+	context.AfterFunc(ctx, func() {
+		changes.mu.Lock()
+		defer changes.mu.Unlock()
+		changes.drop(ch)
+	})
+	return ch
+}
+
+// Publish sends change to every subscriber without waiting for them.
+func (changes *Changes) Publish(change Change) {
+	changes.mu.Lock()
+	defer changes.mu.Unlock()
+	for ch := range changes.subscribers {
+		select {
+		case ch <- change:
+		default:
+			changes.drop(ch)
+		}
+	}
+}
+
+// Close closes the channels of all subscribers. Later subscriptions get a
+// closed channel.
+func (changes *Changes) Close() {
+	changes.mu.Lock()
+	defer changes.mu.Unlock()
+	changes.closed = true
+	for ch := range changes.subscribers {
+		changes.drop(ch)
+	}
+}
+
+// drop removes a subscriber, the caller holds the lock.
+func (changes *Changes) drop(ch chan Change) {
+	if _, ok := changes.subscribers[ch]; ok {
+		delete(changes.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Diff returns the changes turning before into after, matching items by id.
+// Stores which reload their data use it to report changes made by other
+// processes.
+func Diff(before []Item, after []Item) []Change {
+	previous := make(map[int]Item, len(before))
+	for _, item := range before {
+		previous[item.ItemId] = item
+	}
+
+	var diff []Change
+	for _, item := range after {
+		old, ok := previous[item.ItemId]
+		delete(previous, item.ItemId)
+		switch {
+		case !ok:
+			diff = append(diff, Change{Op: OpAdd, Item: item})
+		case !sameItem(old, item):
+			diff = append(diff, Change{Op: OpUpdate, Item: item})
+		}
+	}
+	for _, item := range before {
+		if _, ok := previous[item.ItemId]; ok {
+			diff = append(diff, Change{Op: OpDelete, Item: item})
+		}
+	}
+	return diff
+}
+
+// sameItem compares items by their persisted form, so timestamps read back
+// from a file equal the ones they were written from.
+func sameItem(a Item, b Item) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestChangesPublish(t *testing.T) {
+	var changes Changes
+	ctx, cancel := context.WithCancel(context.Background())
+	first := changes.Subscribe(ctx)
+	second := changes.Subscribe(context.Background())
+
+	changes.Publish(Change{Op: OpAdd, Item: Item{ItemId: 1}})
+	for _, ch := range []<-chan Change{first, second} {
+		if change := <-ch; change.Op != OpAdd || change.Item.ItemId != 1 {
+			t.Errorf("Unexpected change %+v", change)
+		}
+	}
+
+	cancel()
+	if _, ok := <-first; ok {
+		t.Errorf("Expected the channel to be closed once its context is done")
+	}
+	changes.Close()
+	if _, ok := <-second; ok {
+		t.Errorf("Expected the channel to be closed by Close")
+	}
+	if _, ok := <-changes.Subscribe(context.Background()); ok {
+		t.Errorf("Expected a closed channel when subscribing after Close")
+	}
+}
+
+func TestChangesDropsSlowSubscriber(t *testing.T) {
+	var changes Changes
+	ch := changes.Subscribe(context.Background())
+	for i := 0; i <= SubscriberBuffer; i++ {
+		changes.Publish(Change{Op: OpAdd, Item: Item{ItemId: i}})
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+	if received != SubscriberBuffer {
+		t.Errorf("Expected %d buffered changes before the drop, got %d", SubscriberBuffer, received)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := []Item{
+		{ItemId: 1, Description: "Keep"},
+		{ItemId: 2, Description: "Change"},
+		{ItemId: 3, Description: "Delete"},
+	}
+	after := []Item{
+		{ItemId: 1, Description: "Keep"},
+		{ItemId: 2, Description: "Changed"},
+		{ItemId: 4, Description: "Add"},
+	}
+
+	diff := Diff(before, after)
+	expected := []Change{
+		{Op: OpUpdate, Item: after[1]},
+		{Op: OpAdd, Item: after[2]},
+		{Op: OpDelete, Item: before[2]},
+	}
+	if len(diff) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), diff)
+	}
+	for i := range expected {
+		if diff[i].Op != expected[i].Op || diff[i].Item.ItemId != expected[i].Item.ItemId {
+			t.Errorf("Change %d: expected %+v, got %+v", i, expected[i], diff[i])
+		}
+	}
+	if diff := Diff(after, after); len(diff) != 0 {
+		t.Errorf("Expected no changes between equal lists, got %+v", diff)
+	}
+}
@@ -0,0 +1,201 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Priorities are the accepted priority levels, from lowest to highest. An empty
+// priority means none was set.
+var Priorities = []string{"low", "medium", "high"}
+
+// DateLayout is the layout accepted for due dates besides RFC 3339.
+const DateLayout = time.DateOnly
+
+// NewItem validates fields and returns a new Item with the given id, the
+// initial status and its created/updated timestamps set to now.
+func NewItem(id int, fields Item, now time.Time) (Item, error) {
+	if fields.Priority != "" && !slices.Contains(Priorities, fields.Priority) {
+		return Item{}, fmt.Errorf("%w: priority %q of To-Do Item is invalid", ErrValidation, fields.Priority)
+	}
+
+	now = now.UTC()
+	item := Item{
+		ItemId:      id,
+		UUID:        uuid.NewString(),
+		Status:      Statuses[0],
+		Description: fields.Description,
+		Priority:    fields.Priority,
+		Tags:        NormalizeTags(fields.Tags),
+		CreatedAt:   &now,
+		UpdatedAt:   &now,
+	}
+	if fields.Due != nil && !fields.Due.IsZero() {
+		due := *fields.Due
+		item.Due = &due
+	}
+	return item, nil
+}
+
+// ShortIdLength is the number of UUID characters shown as the short id.
+const ShortIdLength = 8
+
+// ShortId returns the short hash identifier of the item, or an empty string
+// for items created before UUIDs were assigned.
+func (item Item) ShortId() string {
+	hex := strings.ReplaceAll(item.UUID, "-", "")
+	return hex[:min(ShortIdLength, len(hex))]
+}
+
+// ResolveId returns the numeric id of the item referenced by ref, which is
+// either a numeric id, a full UUID or a unique prefix of the short id. Short
+// ids made of digits only are tried after the numeric ids.
+func ResolveId(items []Item, ref string) (int, error) {
+	ref = strings.ToLower(strings.TrimSpace(ref))
+	if ref == "" {
+		return 0, fmt.Errorf("%w: missing To-Do Item id", ErrValidation)
+	}
+	id, err := strconv.Atoi(ref)
+	if err == nil && slices.ContainsFunc(items, func(item Item) bool { return item.ItemId == id }) {
+		return id, nil
+	}
+
+	found := 0
+	for _, item := range items {
+		if item.UUID == "" {
+			continue
+		}
+		if item.UUID == ref || strings.HasPrefix(strings.ReplaceAll(item.UUID, "-", ""), ref) {
+			if found != 0 {
+				return 0, fmt.Errorf("%w: To-Do Item id %q is ambiguous", ErrValidation, ref)
+			}
+			found = item.ItemId
+		}
+	}
+	if found == 0 {
+		if err == nil {
+			// Let the store report the missing numeric id.
+			return id, nil
+		}
+		return 0, fmt.Errorf("To-Do Item %q: %w", ref, ErrNotFound)
+	}
+	return found, nil
+}
+
+// fixNextId makes sure NextId is past every id in use, which also derives the
+// sequence for data files written before it was persisted.
+func (data *Data) fixNextId() {
+	for _, item := range data.Items {
+		data.NextId = max(data.NextId, item.ItemId+1)
+	}
+	data.NextId = max(data.NextId, 1)
+}
+
+// ApplyPatch validates patch and applies it to item, maintaining the updated
+// and completed timestamps.
+func ApplyPatch(item *Item, patch ItemPatch, now time.Time) error {
+	if patch.Status != nil && !slices.Contains(Statuses, *patch.Status) {
+		return fmt.Errorf("%w: status %q of To-Do Item is invalid", ErrInvalidStatus, *patch.Status)
+	}
+	if patch.Status != nil {
+		err := CheckTransition(*item, *patch.Status)
+		if err != nil {
+			return err
+		}
+	}
+	if patch.Priority != nil && *patch.Priority != "" && !slices.Contains(Priorities, *patch.Priority) {
+		return fmt.Errorf("%w: priority %q of To-Do Item is invalid", ErrValidation, *patch.Priority)
+	}
+
+	now = now.UTC()
+	if patch.Status != nil && *patch.Status != item.Status {
+		item.Status = *patch.Status
+		if IsCompleted(item.Status) {
+			item.CompletedAt = &now
+		} else {
+			item.CompletedAt = nil
+		}
+	}
+	if patch.Description != nil {
+		item.Description = *patch.Description
+	}
+	if patch.Priority != nil {
+		item.Priority = *patch.Priority
+	}
+	if patch.Due != nil {
+		if patch.Due.IsZero() {
+			item.Due = nil
+		} else {
+			due := *patch.Due
+			item.Due = &due
+		}
+	}
+	if patch.Tags != nil {
+		item.Tags = NormalizeTags(patch.Tags)
+	}
+	item.UpdatedAt = &now
+	return nil
+}
+
+// UpdatePatch returns the patch for UpdateToDoItem, where an empty status or
+// description leaves the field unchanged.
+func UpdatePatch(status string, desc string) ItemPatch {
+	var patch ItemPatch
+	if status != "" {
+		patch.Status = &status
+	}
+	if desc != "" {
+		patch.Description = &desc
+	}
+	return patch
+}
+
+// IsEmpty reports whether the patch changes no field.
+func (patch ItemPatch) IsEmpty() bool {
+	return patch.Status == nil && patch.Description == nil && patch.Priority == nil &&
+		patch.Due == nil && patch.Tags == nil
+}
+
+// NormalizeTags trims the tags and drops empty and duplicate ones.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// ParseTags splits a comma separated list of tags. It never returns nil, so
+// parsing an empty string clears the tags in an ItemPatch.
+func ParseTags(s string) []string {
+	tags := NormalizeTags(strings.Split(s, ","))
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// ParseDue parses a due date given as "2006-01-02" or RFC 3339. An empty string
+// or "none" returns the zero time, which clears the due date in an ItemPatch.
+func ParseDue(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "none" {
+		return time.Time{}, nil
+	}
+	if due, err := time.Parse(DateLayout, s); err == nil {
+		return due, nil
+	}
+	due, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid due date %q, expected %s or RFC 3339", ErrValidation, s, DateLayout)
+	}
+	return due, nil
+}
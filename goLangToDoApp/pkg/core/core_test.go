@@ -0,0 +1,105 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadLegacyItems(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	legacy := `[{"id": 1, "status": "started", "description": "Legacy"}]`
+	if err := os.WriteFile(filePath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := LoadData(filePath)
+	if err != nil {
+		t.Fatalf("Failed to load legacy data file: %v", err)
+	}
+	if data.NextId != 2 {
+		t.Errorf("Expected id sequence derived from legacy items, got %d", data.NextId)
+	}
+	items := data.Items
+	if len(items) != 1 || items[0].Description != "Legacy" || items[0].Status != "started" {
+		t.Errorf("Unexpected legacy items %v", items)
+	}
+	if items[0].Due != nil || items[0].CreatedAt != nil || items[0].Tags != nil {
+		t.Errorf("Missing fields must load as unset, got %v", items[0])
+	}
+}
+
+func TestParseDue(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2030-01-02", time.Date(2030, time.January, 2, 0, 0, 0, 0, time.UTC), false},
+		{"2030-01-02T15:04:05Z", time.Date(2030, time.January, 2, 15, 4, 5, 0, time.UTC), false},
+		{"none", time.Time{}, false},
+		{"", time.Time{}, false},
+		{"tomorrow", time.Time{}, true},
+	}
+	for _, tc := range tests {
+		got, err := ParseDue(tc.in)
+		if (err != nil) != tc.wantErr || !got.Equal(tc.want) {
+			t.Errorf("ParseDue(%q) = %v, %v", tc.in, got, err)
+		}
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	got := ParseTags(" work,,home , work")
+	if len(got) != 2 || got[0] != "work" || got[1] != "home" {
+		t.Errorf("Unexpected tags %v", got)
+	}
+}
+
+func TestResolveId(t *testing.T) {
+	items := []Item{
+		{ItemId: 1, UUID: "0a1b2c3d-0000-4000-8000-000000000001"},
+		{ItemId: 2, UUID: "0a1b9999-0000-4000-8000-000000000002"},
+		{ItemId: 3},
+		{ItemId: 4, UUID: "12345678-0000-4000-8000-000000000004"},
+	}
+	tests := []struct {
+		ref     string
+		want    int
+		wantErr bool
+	}{
+		{"3", 3, false},
+		{"12345678", 4, false},
+		{"42", 42, false},
+		{"0a1b2c3d", 1, false},
+		{"0A1B99", 2, false},
+		{"0a1b9999-0000-4000-8000-000000000002", 2, false},
+		{"0a1b", 0, true},
+		{"ffff", 0, true},
+		{"", 0, true},
+	}
+	for _, tc := range tests {
+		got, err := ResolveId(items, tc.ref)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("ResolveId(%q) = %d, %v", tc.ref, got, err)
+		}
+	}
+}
+
+func TestShortId(t *testing.T) {
+	tests := []struct {
+		uuid string
+		want string
+	}{
+		{"0a1b2c3d-0000-4000-8000-000000000001", "0a1b2c3d"},
+		{"", ""},
+		{"a-b", "ab"},
+		{"0a1b-2c3d-4e", "0a1b2c3d"},
+	}
+	for _, tc := range tests {
+		if got := (Item{UUID: tc.uuid}).ShortId(); got != tc.want {
+			t.Errorf("ShortId of %q = %q, want %q", tc.uuid, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,727 @@
+// Package coretest provides a conformance test suite that every core.Store
+// implementation must pass.
+package coretest
+
+import (
+	"context"
+	"errors"
+	"goLangToDoApp/pkg/core"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// NewStoreFunc opens a store backed by the given data file.
+type NewStoreFunc func(filePath string) (core.Store, error)
+
+// NewBackendStoreFunc opens a store on the given backend.
+type NewBackendStoreFunc func(backend core.Backend) (core.Store, error)
+
+// Run executes the conformance suite against the stores returned by newStore.
+// Every sub-test gets its own data file in a temporary directory.
+func Run(t *testing.T, newStore NewStoreFunc) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, NewStoreFunc)
+	}{
+		{"Empty", testEmpty},
+		{"Add", testAdd},
+		{"UpdateDesc", testUpdateDesc},
+		{"UpdateStatus", testUpdateStatus},
+		{"UpdateInvalidStatus", testUpdateInvalidStatus},
+		{"UpdateMissing", testUpdateMissing},
+		{"Delete", testDelete},
+		{"DeleteMissing", testDeleteMissing},
+		{"Persistence", testPersistence},
+		{"ItemsAreCopies", testItemsAreCopies},
+		{"AddFields", testAddFields},
+		{"AddInvalidPriority", testAddInvalidPriority},
+		{"Patch", testPatch},
+		{"Timestamps", testTimestamps},
+		{"PersistFields", testPersistFields},
+		{"Query", testQuery},
+		{"IdsNotReused", testIdsNotReused},
+		{"UUIDs", testUUIDs},
+		{"Close", testClose},
+		{"Subscribe", testSubscribe},
+		{"UndoRedo", testUndoRedo},
+		{"UndoEmpty", testUndoEmpty},
+		{"SharedFile", testSharedFile},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, newStore)
+		})
+	}
+}
+
+// RunConcurrent checks that the store stays consistent under parallel writers.
+// Only implementations that claim to be concurrent safe should call it.
+func RunConcurrent(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+
+	const writers = 20
+	var wg sync.WaitGroup
+	wg.Add(writers)
+	for i := 0; i < writers; i++ {
+		go func() {
+			defer wg.Done()
+			if err := store.AddNewToDoItem(ctx, "Task"); err != nil {
+				t.Errorf("Failed to add To-Do Item: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	items := getAll(t, store)
+	if len(items) != writers {
+		t.Fatalf("Expected %d To-Do Items, got %d", writers, len(items))
+	}
+	seen := make(map[int]bool)
+	for _, item := range items {
+		if seen[item.ItemId] {
+			t.Errorf("Duplicate To-Do Item id %d", item.ItemId)
+		}
+		seen[item.ItemId] = true
+	}
+}
+
+// failingBackend fails to save while fail is set.
+type failingBackend struct {
+	core.Backend
+	fail bool
+}
+
+func (backend *failingBackend) Save(data core.Data, change core.Change) error {
+	if backend.fail {
+		return errors.New("disk full")
+	}
+	return backend.Backend.Save(data, change)
+}
+
+// RunSaveFailure checks that changes the backend fails to save leave the
+// store as it was.
+func RunSaveFailure(t *testing.T, newStore NewBackendStoreFunc) {
+	ctx := context.Background()
+	backend := &failingBackend{Backend: core.NewJSONBackend(dataFile(t))}
+	store, err := newStore(backend)
+	if err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := store.Close(ctx); err != nil {
+			t.Errorf("Failed to close store: %v", err)
+		}
+	})
+	first, err := store.AddToDoItem(ctx, core.Item{Description: "Task 1"})
+	if err != nil {
+		t.Fatalf("Failed to add To-Do Item: %v", err)
+	}
+	before := getAll(t, store)
+
+	backend.fail = true
+	if _, err := store.AddToDoItem(ctx, core.Item{Description: "Task 2"}); !errors.Is(err, core.ErrStorage) {
+		t.Errorf("Expected ErrStorage on add, got %v", err)
+	}
+	if _, err := store.PatchToDoItem(ctx, first.ItemId, core.UpdatePatch("", "Changed")); !errors.Is(err, core.ErrStorage) {
+		t.Errorf("Expected ErrStorage on update, got %v", err)
+	}
+	if err := store.DeleteToDoItem(ctx, first.ItemId); !errors.Is(err, core.ErrStorage) {
+		t.Errorf("Expected ErrStorage on delete, got %v", err)
+	}
+	if _, err := store.Undo(ctx); !errors.Is(err, core.ErrStorage) {
+		t.Errorf("Expected ErrStorage on undo, got %v", err)
+	}
+	if items := getAll(t, store); !reflect.DeepEqual(items, before) {
+		t.Errorf("Expected the failed changes to leave %+v, got %+v", before, items)
+	}
+
+	backend.fail = false
+	added, err := store.AddToDoItem(ctx, core.Item{Description: "Task 2"})
+	if err != nil {
+		t.Fatalf("Failed to add To-Do Item: %v", err)
+	}
+	if added.ItemId != first.ItemId+1 {
+		t.Errorf("Expected the failed add not to use up id %d, got %d", first.ItemId+1, added.ItemId)
+	}
+	for _, id := range []int{added.ItemId, first.ItemId} {
+		revert, err := store.Undo(ctx)
+		if err != nil || revert.Change.Op != core.OpAdd || revert.Change.Item.ItemId != id {
+			t.Errorf("Expected to undo the add of %d, got %+v (%v)", id, revert, err)
+		}
+	}
+	if _, err := store.Undo(ctx); !errors.Is(err, core.ErrNothingToUndo) {
+		t.Errorf("Expected the failed changes not to be journaled, got %v", err)
+	}
+}
+
+func dataFile(t *testing.T) string {
+	return filepath.Join(t.TempDir(), "ToDoData.json")
+}
+
+func open(t *testing.T, newStore NewStoreFunc, filePath string) core.Store {
+	t.Helper()
+	store, err := newStore(filePath)
+	if err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := store.Close(context.Background()); err != nil {
+			t.Errorf("Failed to close store: %v", err)
+		}
+	})
+	return store
+}
+
+func getAll(t *testing.T, store core.Store) []core.Item {
+	t.Helper()
+	ctx := context.Background()
+	items, err := store.GetAllToDoItems(ctx)
+	if err != nil {
+		t.Fatalf("Failed to Get To-Do Item(s): %v", err)
+	}
+	return items
+}
+
+func find(items []core.Item, id int) (core.Item, bool) {
+	for _, item := range items {
+		if item.ItemId == id {
+			return item, true
+		}
+	}
+	return core.Item{}, false
+}
+
+func testEmpty(t *testing.T, newStore NewStoreFunc) {
+	store := open(t, newStore, dataFile(t))
+	if items := getAll(t, store); len(items) != 0 {
+		t.Errorf("Expected empty store, got %d item(s)", len(items))
+	}
+}
+
+func testAdd(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	for _, desc := range []string{"Task 1", "Task 2"} {
+		if err := store.AddNewToDoItem(ctx, desc); err != nil {
+			t.Fatalf("Failed to Add New To-Do Item: %v", err)
+		}
+	}
+
+	items := getAll(t, store)
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 To-Do Items, got %d", len(items))
+	}
+	for i, item := range items {
+		if item.ItemId != i+1 {
+			t.Errorf("Expected id %d, got %d", i+1, item.ItemId)
+		}
+		if item.Status != core.Statuses[0] {
+			t.Errorf("Expected status %q, got %q", core.Statuses[0], item.Status)
+		}
+	}
+	if items[1].Description != "Task 2" {
+		t.Errorf("Expected description %q, got %q", "Task 2", items[1].Description)
+	}
+}
+
+func testUpdateDesc(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	if err := store.AddNewToDoItem(ctx, "Test Description"); err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+	if err := store.UpdateToDoItem(ctx, 1, "", "Updated Description"); err != nil {
+		t.Fatalf("Failed to Update To-Do Item: %v", err)
+	}
+
+	item, _ := find(getAll(t, store), 1)
+	if item.Description != "Updated Description" {
+		t.Errorf("Failed to Update To-Do Item Description")
+	}
+	if item.Status != core.Statuses[0] {
+		t.Errorf("Empty status must leave the status unchanged, got %q", item.Status)
+	}
+}
+
+func testUpdateStatus(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	if err := store.AddNewToDoItem(ctx, "Test Description"); err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+	if err := store.UpdateToDoItem(ctx, 1, "completed", ""); err != nil {
+		t.Fatalf("Failed to Update To-Do Item: %v", err)
+	}
+
+	item, _ := find(getAll(t, store), 1)
+	if item.Status != "completed" {
+		t.Errorf("Failed to Update To-Do Item Status")
+	}
+	if item.Description != "Test Description" {
+		t.Errorf("Empty description must leave the description unchanged, got %q", item.Description)
+	}
+}
+
+func testUpdateInvalidStatus(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	if err := store.AddNewToDoItem(ctx, "Test Description"); err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+	if err := store.UpdateToDoItem(ctx, 1, "unknown", ""); !errors.Is(err, core.ErrInvalidStatus) {
+		t.Errorf("Expected core.ErrInvalidStatus for invalid status, got %v", err)
+	}
+}
+
+func testUpdateMissing(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	if err := store.UpdateToDoItem(ctx, 42, "", "Nothing"); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("Expected core.ErrNotFound when updating a missing To-Do Item, got %v", err)
+	}
+}
+
+func testDelete(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	for _, desc := range []string{"Task 1", "Task 2"} {
+		if err := store.AddNewToDoItem(ctx, desc); err != nil {
+			t.Fatalf("Failed to Add New To-Do Item: %v", err)
+		}
+	}
+	if err := store.DeleteToDoItem(ctx, 1); err != nil {
+		t.Fatalf("Failed to Delete To-Do Item: %v", err)
+	}
+
+	items := getAll(t, store)
+	if len(items) != 1 || items[0].ItemId != 2 {
+		t.Errorf("Expected only To-Do Item 2 to remain, got %v", items)
+	}
+}
+
+func testDeleteMissing(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	if err := store.DeleteToDoItem(ctx, 42); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("Expected core.ErrNotFound when deleting a missing To-Do Item, got %v", err)
+	}
+}
+
+func testPersistence(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	filePath := dataFile(t)
+	store := open(t, newStore, filePath)
+	if err := store.AddNewToDoItem(ctx, "Persisted"); err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+	if err := store.UpdateToDoItem(ctx, 1, "started", ""); err != nil {
+		t.Fatalf("Failed to Update To-Do Item: %v", err)
+	}
+
+	reopened := open(t, newStore, filePath)
+	item, ok := find(getAll(t, reopened), 1)
+	if !ok || item.Description != "Persisted" || item.Status != "started" {
+		t.Errorf("To-Do Item was not persisted, got %v", item)
+	}
+}
+
+func testItemsAreCopies(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	if err := store.AddNewToDoItem(ctx, "Original"); err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+
+	items := getAll(t, store)
+	items[0].Description = "Changed"
+
+	item, _ := find(getAll(t, store), 1)
+	if item.Description != "Original" {
+		t.Errorf("Returned To-Do Items must not alias the store's state")
+	}
+}
+
+func richItem() core.Item {
+	due := time.Date(2030, time.January, 2, 0, 0, 0, 0, time.UTC)
+	return core.Item{
+		Description: "Rich",
+		Priority:    "high",
+		Due:         &due,
+		Tags:        []string{"work", " home ", "work"},
+	}
+}
+
+func testAddFields(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	added, err := store.AddToDoItem(ctx, richItem())
+	if err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+	if added.ItemId != 1 || added.Status != core.Statuses[0] {
+		t.Errorf("Unexpected id or status in %v", added)
+	}
+
+	item, _ := find(getAll(t, store), added.ItemId)
+	if item.Priority != "high" {
+		t.Errorf("Expected priority %q, got %q", "high", item.Priority)
+	}
+	if item.Due == nil || !item.Due.Equal(*richItem().Due) {
+		t.Errorf("Expected due date %v, got %v", richItem().Due, item.Due)
+	}
+	if !slices.Equal(item.Tags, []string{"work", "home"}) {
+		t.Errorf("Expected normalized tags, got %v", item.Tags)
+	}
+}
+
+func testAddInvalidPriority(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	if _, err := store.AddToDoItem(ctx, core.Item{Description: "Task", Priority: "urgent"}); !errors.Is(err, core.ErrValidation) {
+		t.Errorf("Expected core.ErrValidation for invalid priority, got %v", err)
+	}
+	if items := getAll(t, store); len(items) != 0 {
+		t.Errorf("Invalid To-Do Item must not be added, got %v", items)
+	}
+}
+
+func testPatch(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	if _, err := store.AddToDoItem(ctx, richItem()); err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+
+	low := "low"
+	patched, err := store.PatchToDoItem(ctx, 1, core.ItemPatch{Priority: &low, Due: &time.Time{}, Tags: []string{}})
+	if err != nil {
+		t.Fatalf("Failed to Patch To-Do Item: %v", err)
+	}
+	if patched.Priority != "low" || patched.Due != nil || len(patched.Tags) != 0 {
+		t.Errorf("Unexpected patched To-Do Item %v", patched)
+	}
+	if patched.Description != "Rich" {
+		t.Errorf("Unpatched fields must be left unchanged, got %v", patched)
+	}
+
+	if _, err := store.PatchToDoItem(ctx, 42, core.ItemPatch{Priority: &low}); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("Expected core.ErrNotFound when patching a missing To-Do Item, got %v", err)
+	}
+}
+
+func testTimestamps(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	added, err := store.AddToDoItem(ctx, core.Item{Description: "Task"})
+	if err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+	if added.CreatedAt == nil || added.UpdatedAt == nil || added.CompletedAt != nil {
+		t.Fatalf("Unexpected timestamps on new To-Do Item %v", added)
+	}
+
+	done := core.CompletedStatuses[0]
+	completed, err := store.PatchToDoItem(ctx, 1, core.ItemPatch{Status: &done})
+	if err != nil {
+		t.Fatalf("Failed to Patch To-Do Item: %v", err)
+	}
+	if completed.CompletedAt == nil {
+		t.Errorf("Completed timestamp was not set")
+	}
+	if completed.UpdatedAt.Before(*added.UpdatedAt) || !completed.CreatedAt.Equal(*added.CreatedAt) {
+		t.Errorf("Unexpected timestamps after update %v", completed)
+	}
+
+	reopened, err := store.PatchToDoItem(ctx, 1, core.ItemPatch{Status: &core.Statuses[0]})
+	if err != nil {
+		t.Fatalf("Failed to Patch To-Do Item: %v", err)
+	}
+	if reopened.CompletedAt != nil {
+		t.Errorf("Completed timestamp must be cleared when reopening")
+	}
+}
+
+func testPersistFields(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	filePath := dataFile(t)
+	store := open(t, newStore, filePath)
+	if _, err := store.AddToDoItem(ctx, richItem()); err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+
+	item, _ := find(getAll(t, open(t, newStore, filePath)), 1)
+	if item.Priority != "high" || item.Due == nil || len(item.Tags) != 2 || item.CreatedAt == nil {
+		t.Errorf("To-Do Item fields were not persisted, got %v", item)
+	}
+}
+
+func testQuery(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	for _, fields := range []core.Item{
+		{Description: "Write report", Priority: "low", Tags: []string{"work"}},
+		{Description: "Buy milk", Priority: "high"},
+		{Description: "Review report", Priority: "medium", Tags: []string{"work"}},
+	} {
+		if _, err := store.AddToDoItem(ctx, fields); err != nil {
+			t.Fatalf("Failed to Add New To-Do Item: %v", err)
+		}
+	}
+
+	page, err := store.QueryToDoItems(ctx, core.Query{Text: "report", SortBy: core.SortByPriority, Desc: true, Limit: 1})
+	if err != nil {
+		t.Fatalf("Failed to Query To-Do Items: %v", err)
+	}
+	if page.Total != 2 || len(page.Items) != 1 || page.Items[0].ItemId != 3 || page.NextOffset != 1 {
+		t.Errorf("Unexpected page %+v", page)
+	}
+
+	if _, err := store.QueryToDoItems(ctx, core.Query{SortBy: "colour"}); !errors.Is(err, core.ErrValidation) {
+		t.Errorf("Expected core.ErrValidation for invalid sort key, got %v", err)
+	}
+}
+
+func testIdsNotReused(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	filePath := dataFile(t)
+	store := open(t, newStore, filePath)
+	for _, desc := range []string{"Task 1", "Task 2"} {
+		if err := store.AddNewToDoItem(ctx, desc); err != nil {
+			t.Fatalf("Failed to Add New To-Do Item: %v", err)
+		}
+	}
+	if err := store.DeleteToDoItem(ctx, 2); err != nil {
+		t.Fatalf("Failed to Delete To-Do Item: %v", err)
+	}
+
+	added, err := store.AddToDoItem(ctx, core.Item{Description: "Task 3"})
+	if err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+	if added.ItemId != 3 {
+		t.Errorf("Expected id 3 after deleting the last item, got %d", added.ItemId)
+	}
+
+	if err := store.DeleteToDoItem(ctx, 3); err != nil {
+		t.Fatalf("Failed to Delete To-Do Item: %v", err)
+	}
+	reopened := open(t, newStore, filePath)
+	added, err = reopened.AddToDoItem(ctx, core.Item{Description: "Task 4"})
+	if err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+	if added.ItemId != 4 {
+		t.Errorf("Expected id 4 after restart, got %d", added.ItemId)
+	}
+}
+
+func testUUIDs(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	first, err := store.AddToDoItem(ctx, core.Item{Description: "Task 1"})
+	if err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+	second, err := store.AddToDoItem(ctx, core.Item{Description: "Task 2"})
+	if err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+	if first.UUID == "" || first.UUID == second.UUID {
+		t.Errorf("Expected distinct UUIDs, got %q and %q", first.UUID, second.UUID)
+	}
+
+	id, err := core.ResolveId(getAll(t, store), second.ShortId())
+	if err != nil || id != second.ItemId {
+		t.Errorf("Expected short id to resolve to %d, got %d (%v)", second.ItemId, id, err)
+	}
+}
+
+func testClose(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	filePath := dataFile(t)
+	store := open(t, newStore, filePath)
+	if err := store.AddNewToDoItem(ctx, "Task 1"); err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+
+	if err := store.Close(ctx); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+	if err := store.Close(ctx); err != nil {
+		t.Errorf("Closing twice must not fail, got %v", err)
+	}
+	if err := store.AddNewToDoItem(ctx, "Task 2"); !errors.Is(err, core.ErrClosed) {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+	if _, err := store.GetAllToDoItems(ctx); !errors.Is(err, core.ErrClosed) {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+
+	items := getAll(t, open(t, newStore, filePath))
+	if len(items) != 1 {
+		t.Errorf("Expected 1 item after reopening, got %d", len(items))
+	}
+}
+
+func testSubscribe(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	changes := store.Subscribe(ctx)
+
+	item, err := store.AddToDoItem(ctx, core.Item{Description: "Task 1"})
+	if err != nil {
+		t.Fatalf("Failed to add To-Do Item: %v", err)
+	}
+	if err := store.UpdateToDoItem(ctx, item.ItemId, "started", ""); err != nil {
+		t.Fatalf("Failed to update To-Do Item: %v", err)
+	}
+	if err := store.DeleteToDoItem(ctx, 99); err == nil {
+		t.Fatalf("Expected error deleting a missing To-Do Item")
+	}
+	if err := store.DeleteToDoItem(ctx, item.ItemId); err != nil {
+		t.Fatalf("Failed to delete To-Do Item: %v", err)
+	}
+
+	for _, expected := range []core.Change{
+		{Op: core.OpAdd, Item: core.Item{ItemId: item.ItemId, Status: core.Statuses[0]}},
+		{Op: core.OpUpdate, Item: core.Item{ItemId: item.ItemId, Status: "started"}},
+		{Op: core.OpDelete, Item: core.Item{ItemId: item.ItemId, Status: "started"}},
+	} {
+		select {
+		case change := <-changes:
+			if change.Op != expected.Op || change.Item.ItemId != expected.Item.ItemId || change.Item.Status != expected.Item.Status {
+				t.Errorf("Expected %s of item %d with status %s, got %+v",
+					expected.Op, expected.Item.ItemId, expected.Item.Status, change)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a %s change", expected.Op)
+		}
+	}
+
+	if err := store.Close(ctx); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+	select {
+	case change, ok := <-changes:
+		if ok {
+			t.Errorf("Expected the subscription to end on Close, got %+v", change)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the subscription to end on Close")
+	}
+}
+
+func testUndoRedo(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	filePath := dataFile(t)
+	store, err := newStore(filePath)
+	if err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	item, err := store.AddToDoItem(ctx, core.Item{Description: "Task 1", Tags: []string{"home"}})
+	if err != nil {
+		t.Fatalf("Failed to add To-Do Item: %v", err)
+	}
+	if err := store.UpdateToDoItem(ctx, item.ItemId, "", "Overwritten"); err != nil {
+		t.Fatalf("Failed to update To-Do Item: %v", err)
+	}
+	if err := store.DeleteToDoItem(ctx, item.ItemId); err != nil {
+		t.Fatalf("Failed to delete To-Do Item: %v", err)
+	}
+
+	revert, err := store.Undo(ctx)
+	if err != nil {
+		t.Fatalf("Failed to undo the delete: %v", err)
+	}
+	if revert.Journal != core.JournalUndo || revert.Change.Op != core.OpDelete ||
+		revert.Item == nil || revert.Item.Description != "Overwritten" {
+		t.Errorf("Expected the delete as recorded and the restored item, got %+v", revert)
+	}
+	revert, err = store.Undo(ctx)
+	if err != nil {
+		t.Fatalf("Failed to undo the update: %v", err)
+	}
+	if revert.Change.Op != core.OpUpdate || revert.Change.Item.Description != "Overwritten" ||
+		revert.Item == nil || revert.Item.Description != "Task 1" {
+		t.Errorf("Expected the update as recorded and the previous item, got %+v", revert)
+	}
+	restored, ok := find(getAll(t, store), item.ItemId)
+	if !ok || restored.Description != "Task 1" || !slices.Equal(restored.Tags, []string{"home"}) {
+		t.Errorf("Expected the original To-Do Item to be restored, got %+v", restored)
+	}
+
+	// The journal is persisted, so a later process can redo.
+	if err := store.Close(ctx); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+	store = open(t, newStore, filePath)
+	revert, err = store.Redo(ctx)
+	if err != nil {
+		t.Fatalf("Failed to redo the update: %v", err)
+	}
+	if revert.Journal != core.JournalRedo || revert.Change.Op != core.OpUpdate ||
+		revert.Item == nil || revert.Item.Description != "Overwritten" {
+		t.Errorf("Expected the update to be redone, got %+v", revert)
+	}
+	if item, _ := find(getAll(t, store), item.ItemId); item.Description != "Overwritten" {
+		t.Errorf("Expected the redone description, got %q", item.Description)
+	}
+
+	if err := store.AddNewToDoItem(ctx, "Task 2"); err != nil {
+		t.Fatalf("Failed to add To-Do Item: %v", err)
+	}
+	if _, err := store.Redo(ctx); !errors.Is(err, core.ErrNothingToRedo) {
+		t.Errorf("Expected a new change to clear the changes to redo, got %v", err)
+	}
+}
+
+func testUndoEmpty(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := open(t, newStore, dataFile(t))
+	if _, err := store.Undo(ctx); !errors.Is(err, core.ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo, got %v", err)
+	}
+	if _, err := store.Redo(ctx); !errors.Is(err, core.ErrNothingToRedo) {
+		t.Errorf("Expected ErrNothingToRedo, got %v", err)
+	}
+}
+
+// testSharedFile has two stores, as in two processes, add to the same data
+// file. The store which is behind must not hand out an id taken already.
+func testSharedFile(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	filePath := dataFile(t)
+	first := open(t, newStore, filePath)
+	second := open(t, newStore, filePath)
+
+	added, err := first.AddToDoItem(ctx, core.Item{Description: "Task 1"})
+	if err != nil || added.ItemId != 1 {
+		t.Fatalf("Expected the first store to add id 1, got %d (%v)", added.ItemId, err)
+	}
+	if _, err := second.AddToDoItem(ctx, core.Item{Description: "Task 2"}); !errors.Is(err, core.ErrConflict) {
+		t.Fatalf("Expected ErrConflict from the store behind, got %v", err)
+	}
+	added, err = second.AddToDoItem(ctx, core.Item{Description: "Task 2"})
+	if err != nil || added.ItemId != 2 {
+		t.Fatalf("Expected the retry to add id 2, got %d (%v)", added.ItemId, err)
+	}
+	if _, err := first.AddToDoItem(ctx, core.Item{Description: "Task 3"}); !errors.Is(err, core.ErrConflict) {
+		t.Fatalf("Expected ErrConflict from the store behind, got %v", err)
+	}
+	added, err = first.AddToDoItem(ctx, core.Item{Description: "Task 3"})
+	if err != nil || added.ItemId != 3 {
+		t.Fatalf("Expected the retry to add id 3, got %d (%v)", added.ItemId, err)
+	}
+
+	items := getAll(t, open(t, newStore, filePath))
+	for id, desc := range map[int]string{1: "Task 1", 2: "Task 2", 3: "Task 3"} {
+		if item, ok := find(items, id); !ok || item.Description != desc {
+			t.Errorf("Expected %q with id %d, got %+v", desc, id, items)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned by stores, wrapped with details. Match them with
+// errors.Is.
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrInvalidStatus = errors.New("invalid status")
+	ErrValidation    = errors.New("validation failed")
+	ErrStorage       = errors.New("storage failure")
+	ErrConflict      = errors.New("conflict")
+	ErrClosed        = errors.New("store closed")
+)
+
+// ErrNothingToUndo and ErrNothingToRedo are returned by Undo and Redo when the
+// journal is empty. They match ErrConflict.
+var (
+	ErrNothingToUndo = fmt.Errorf("nothing to undo: %w", ErrConflict)
+	ErrNothingToRedo = fmt.Errorf("nothing to redo: %w", ErrConflict)
+)
+
+// NotFoundError returns the error for a missing To-Do Item.
+func NotFoundError(id int) error {
+	return fmt.Errorf("To-Do Item %d: %w", id, ErrNotFound)
+}
+
+// StorageError wraps a failure of the storage backend.
+func StorageError(err error) error {
+	if err == nil || errors.Is(err, ErrStorage) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrStorage, err)
+}
+
+// Is makes a rejected transition match ErrConflict, as the item's current
+// status is what prevents the change.
+func (err *TransitionError) Is(target error) bool {
+	return target == ErrConflict
+}
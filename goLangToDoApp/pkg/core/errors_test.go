@@ -0,0 +1,28 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	var transitionErr error = &TransitionError{ItemId: 1, From: "completed", To: "started"}
+	if !errors.Is(transitionErr, ErrConflict) {
+		t.Errorf("Expected TransitionError to match ErrConflict")
+	}
+	if !errors.Is(NotFoundError(3), ErrNotFound) {
+		t.Errorf("Expected NotFoundError to match ErrNotFound")
+	}
+
+	storageErr := StorageError(os.ErrPermission)
+	if !errors.Is(storageErr, ErrStorage) || !errors.Is(storageErr, os.ErrPermission) {
+		t.Errorf("Expected StorageError to match ErrStorage and the cause, got %v", storageErr)
+	}
+	if StorageError(storageErr) != storageErr {
+		t.Errorf("StorageError must not wrap twice")
+	}
+	if StorageError(nil) != nil {
+		t.Errorf("StorageError(nil) must be nil")
+	}
+}
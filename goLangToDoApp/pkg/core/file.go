@@ -0,0 +1,157 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// BackupSuffix is appended to the data file name to get the rolling backup of
+// the last good file.
+const BackupSuffix = ".bak"
+
+// writeData writes the payload into the temporary file. Tests replace it to
+// simulate partial writes.
+var writeData = func(f *os.File, data []byte) error {
+	_, err := f.Write(data)
+	return err
+}
+
+// LoadData reads the To-Do data stored in filePath. When the file is missing
+// or cannot be unmarshalled, the data is recovered from the backup file.
+func LoadData(filePath string) (Data, error) {
+	data, err := readData(filePath)
+	if err == nil {
+		return data, nil
+	}
+
+	backup, backupErr := readData(filePath + BackupSuffix)
+	if backupErr != nil {
+		if os.IsNotExist(err) {
+			if os.IsNotExist(backupErr) {
+				return Data{NextId: 1}, nil
+			}
+			return Data{}, backupErr
+		}
+		return Data{}, err
+	}
+
+	slog.Warn("Recovered To-Do items from backup file.", "file", filePath, "error", err)
+	return backup, nil
+}
+
+// SaveData persists the To-Do data to filePath. The data is written to a
+// temporary file which is synced and renamed over the previous file, which in
+// turn is kept as a backup, so a crash never leaves a truncated data file.
+func SaveData(filePath string, data Data) error {
+	byteValue, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		return fmt.Errorf("error marshalling To-Do items: %w", err)
+	}
+
+	// A corrupt data file, which LoadData has just recovered from, must not
+	// replace the good backup.
+	err = writeFileAtomic(filePath, byteValue, 0644, func(previous string) bool {
+		_, err := readData(previous)
+		return err == nil
+	})
+	if err != nil {
+		return fmt.Errorf("error saving to file %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// WriteFileAtomic replaces filePath with data using write-to-temp, fsync and
+// rename, giving it the permissions perm. The previous content of filePath is
+// kept in filePath+BackupSuffix with the same permissions.
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	return writeFileAtomic(filePath, data, perm, nil)
+}
+
+// writeFileAtomic is WriteFileAtomic keeping the previous content as the
+// backup only when keep, if set, accepts it.
+func writeFileAtomic(filePath string, data []byte, perm os.FileMode, keep func(previous string) bool) error {
+	dir := filepath.Dir(filePath)
+	tmp, err := os.CreateTemp(dir, filepath.Base(filePath)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	err = writeData(tmp, data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpName, perm)
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+
+	if keep == nil || keep(filePath) {
+		err = os.Rename(filePath, filePath+BackupSuffix)
+		if err == nil {
+			// The previous file may predate perm.
+			err = os.Chmod(filePath+BackupSuffix, perm)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			_ = os.Remove(tmpName)
+			return err
+		}
+	}
+
+	err = os.Rename(tmpName, filePath)
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	return syncDir(dir)
+}
+
+// readData reads a data file, accepting the legacy format which was a plain
+// JSON array of items without the id sequence.
+func readData(filePath string) (Data, error) {
+	byteValue, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Data{}, err
+		}
+		return Data{}, fmt.Errorf("error reading file %s: %w", filePath, err)
+	}
+
+	var data Data
+	byteValue = bytes.TrimSpace(byteValue)
+	if len(byteValue) > 0 && byteValue[0] == '[' {
+		err = json.Unmarshal(byteValue, &data.Items)
+	} else if len(byteValue) > 0 {
+		err = json.Unmarshal(byteValue, &data)
+	}
+	if err != nil {
+		return Data{}, fmt.Errorf("error unmarshalling To-Do items: %w", err)
+	}
+	data.fixNextId()
+	return data, nil
+}
+
+// syncDir flushes the directory entry so the rename itself survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Some platforms do not support syncing directories, the rename has
+	// already happened at this point so that is not treated as a failure.
+	_ = d.Sync()
+	return nil
+}
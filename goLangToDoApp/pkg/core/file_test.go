@@ -0,0 +1,156 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func testData(descs ...string) Data {
+	data := Data{NextId: len(descs) + 1}
+	for i, desc := range descs {
+		data.Items = append(data.Items, Item{ItemId: i + 1, Status: Statuses[0], Description: desc})
+	}
+	return data
+}
+
+func TestSaveDataKeepsBackup(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+
+	if err := SaveData(filePath, testData("first")); err != nil {
+		t.Fatalf("Failed to save To-Do items: %v", err)
+	}
+	if err := SaveData(filePath, testData("first", "second")); err != nil {
+		t.Fatalf("Failed to save To-Do items: %v", err)
+	}
+
+	data, err := readData(filePath)
+	if err != nil || len(data.Items) != 2 {
+		t.Errorf("Expected 2 items in data file, got %v (%v)", data, err)
+	}
+	backup, err := readData(filePath + BackupSuffix)
+	if err != nil || len(backup.Items) != 1 {
+		t.Errorf("Expected previous version in backup file, got %v (%v)", backup, err)
+	}
+}
+
+func TestSaveDataPartialWrite(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "ToDoData.json")
+	if err := SaveData(filePath, testData("first")); err != nil {
+		t.Fatalf("Failed to save To-Do items: %v", err)
+	}
+
+	// Simulate a full disk half way through the write.
+	original := writeData
+	writeData = func(f *os.File, data []byte) error {
+		_, _ = f.Write(data[:len(data)/2])
+		return syscall.ENOSPC
+	}
+	t.Cleanup(func() { writeData = original })
+
+	err := SaveData(filePath, testData("first", "second"))
+	if !errors.Is(err, syscall.ENOSPC) {
+		t.Fatalf("Expected ENOSPC, got %v", err)
+	}
+
+	data, err := LoadData(filePath)
+	if err != nil || len(data.Items) != 1 || data.Items[0].Description != "first" {
+		t.Errorf("Data file must be untouched after a failed write, got %v (%v)", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Temporary file was not cleaned up, directory has %d entries", len(entries))
+	}
+}
+
+func TestLoadDataRecoversTruncatedFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	if err := SaveData(filePath, testData("first")); err != nil {
+		t.Fatalf("Failed to save To-Do items: %v", err)
+	}
+	if err := SaveData(filePath, testData("first", "second")); err != nil {
+		t.Fatalf("Failed to save To-Do items: %v", err)
+	}
+
+	// Simulate a crash of an in-place write that left half a file behind.
+	raw, _ := os.ReadFile(filePath)
+	if err := os.WriteFile(filePath, raw[:len(raw)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := LoadData(filePath)
+	if err != nil {
+		t.Fatalf("Expected recovery from backup, got %v", err)
+	}
+	if len(data.Items) != 1 || data.Items[0].Description != "first" {
+		t.Errorf("Expected backup items, got %v", data.Items)
+	}
+}
+
+func TestSaveDataKeepsGoodBackup(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	for _, data := range []Data{testData("first"), testData("first", "second")} {
+		if err := SaveData(filePath, data); err != nil {
+			t.Fatalf("Failed to save To-Do items: %v", err)
+		}
+	}
+	if err := os.WriteFile(filePath, []byte(`{"items": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := LoadData(filePath)
+	if err != nil || len(data.Items) != 1 {
+		t.Fatalf("Expected 1 item recovered from the backup, got %v (%v)", data, err)
+	}
+	if err := SaveData(filePath, testData("first", "third")); err != nil {
+		t.Fatalf("Failed to save To-Do items: %v", err)
+	}
+
+	backup, err := readData(filePath + BackupSuffix)
+	if err != nil || len(backup.Items) != 1 {
+		t.Errorf("Expected the backup to survive the corrupt data file, got %v (%v)", backup, err)
+	}
+	data, err = readData(filePath)
+	if err != nil || len(data.Items) != 2 {
+		t.Errorf("Expected 2 items in data file, got %v (%v)", data, err)
+	}
+}
+
+func TestLoadDataRecoversMissingFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	if err := SaveData(filePath, testData("first")); err != nil {
+		t.Fatalf("Failed to save To-Do items: %v", err)
+	}
+
+	// Simulate a crash between moving the old file to the backup and
+	// moving the new file into place.
+	if err := os.Rename(filePath, filePath+BackupSuffix); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := LoadData(filePath)
+	if err != nil || len(data.Items) != 1 {
+		t.Errorf("Expected recovery from backup, got %v (%v)", data, err)
+	}
+}
+
+func TestLoadDataCorruptWithoutBackup(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	if err := os.WriteFile(filePath, []byte(`[{"id": 1,`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadData(filePath); err == nil {
+		t.Errorf("Expected error for corrupt data file without backup")
+	}
+}
+
+func TestLoadDataMissing(t *testing.T) {
+	data, err := LoadData(filepath.Join(t.TempDir(), "ToDoData.json"))
+	if err != nil || len(data.Items) != 0 || data.NextId != 1 {
+		t.Errorf("Expected empty data for missing data file, got %v (%v)", data, err)
+	}
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+)
+
+// JournalSize is the number of changes a store keeps to undo.
+const JournalSize = 50
+
+// Values of Change.Journal.
+const (
+	JournalUndo = "undo"
+	JournalRedo = "redo"
+)
+
+// Journal records the last changes of a store so they can be undone, and the
+// undone changes so they can be redone. Both lists hold the changes as they
+// were first made, the most recent last.
+type Journal struct {
+	Done   []Change `json:"undo,omitempty"`
+	Undone []Change `json:"redo,omitempty"`
+}
+
+// Record adds change to the journal. A new change clears the changes to redo,
+// an undo or redo moves the change between the lists. Stores call it for
+// every change they save, and the write-ahead log replays it the same way.
+func (journal *Journal) Record(change Change) {
+	switch change.Journal {
+	case JournalUndo:
+		if len(journal.Done) > 0 {
+			last := journal.Done[len(journal.Done)-1]
+			journal.Done = journal.Done[:len(journal.Done)-1]
+			journal.Undone = append(journal.Undone, last)
+		}
+	case JournalRedo:
+		if len(journal.Undone) > 0 {
+			last := journal.Undone[len(journal.Undone)-1]
+			journal.Undone = journal.Undone[:len(journal.Undone)-1]
+			journal.Done = append(journal.Done, last)
+		}
+	default:
+		journal.Done = append(journal.Done, change)
+		if len(journal.Done) > JournalSize {
+			journal.Done = journal.Done[len(journal.Done)-JournalSize:]
+		}
+		journal.Undone = nil
+	}
+}
+
+// Clone returns a copy of the journal to record changes in without changing
+// the journal itself.
+func (journal Journal) Clone() Journal {
+	return Journal{Done: slices.Clone(journal.Done), Undone: slices.Clone(journal.Undone)}
+}
+
+// Revert reports an undo or redo: the journal entry undone or made again, as
+// it was first recorded, and the item it left.
+type Revert struct {
+	// Journal is JournalUndo or JournalRedo.
+	Journal string `json:"journal"`
+	Change  Change `json:"change"`
+	// Item is the To-Do Item after the revert, nil when it was removed.
+	Item *Item `json:"item"`
+}
+
+// Undo returns the change reverting the last change, which the store applies
+// and records, and the Revert reporting it. It fails with ErrNothingToUndo
+// when there is none.
+func (journal *Journal) Undo() (Change, Revert, error) {
+	if len(journal.Done) == 0 {
+		return Change{}, Revert{}, ErrNothingToUndo
+	}
+	last := journal.Done[len(journal.Done)-1]
+	undo := Change{Item: last.Item, Journal: JournalUndo}
+	switch last.Op {
+	case OpAdd:
+		undo.Op = OpDelete
+	case OpDelete:
+		undo.Op = OpAdd
+	default:
+		undo.Op = OpUpdate
+		if last.Before != nil {
+			undo.Item = *last.Before
+		}
+		undo.Before = &last.Item
+	}
+	return undo, newRevert(last, undo), nil
+}
+
+// Redo returns the change making the last undone change again, and the Revert
+// reporting it. It fails with ErrNothingToRedo when there is none.
+func (journal *Journal) Redo() (Change, Revert, error) {
+	if len(journal.Undone) == 0 {
+		return Change{}, Revert{}, ErrNothingToRedo
+	}
+	last := journal.Undone[len(journal.Undone)-1]
+	redo := last
+	redo.Journal = JournalRedo
+	return redo, newRevert(last, redo), nil
+}
+
+// newRevert returns the Revert of entry made by the change applied.
+func newRevert(entry Change, applied Change) Revert {
+	revert := Revert{Journal: applied.Journal, Change: entry}
+	if applied.Op != OpDelete {
+		item := applied.Item
+		revert.Item = &item
+	}
+	return revert
+}
+
+// Summary describes what the revert did, e.g. "undid the deletion of To-Do
+// Item 3".
+func (revert Revert) Summary() string {
+	verb := "undid"
+	if revert.Journal == JournalRedo {
+		verb = "redid"
+	}
+	noun := map[string]string{OpAdd: "addition", OpUpdate: "update", OpDelete: "deletion"}[revert.Change.Op]
+	return fmt.Sprintf("%s the %s of To-Do Item %d", verb, noun, revert.Change.Item.ItemId)
+}
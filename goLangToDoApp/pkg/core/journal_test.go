@@ -0,0 +1,93 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestJournalUndoRedo(t *testing.T) {
+	before := Item{ItemId: 1, Status: "not-started", Description: "first"}
+	after := Item{ItemId: 1, Status: "started", Description: "first"}
+
+	var journal Journal
+	if _, _, err := journal.Undo(); !errors.Is(err, ErrNothingToUndo) || !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrNothingToUndo matching ErrConflict, got %v", err)
+	}
+	journal.Record(Change{Op: OpAdd, Item: before})
+	journal.Record(Change{Op: OpUpdate, Item: after, Before: &before})
+
+	undo, revert, err := journal.Undo()
+	if err != nil {
+		t.Fatalf("Failed to undo: %v", err)
+	}
+	if undo.Op != OpUpdate || undo.Journal != JournalUndo || undo.Item.Status != "not-started" {
+		t.Errorf("Expected the update to be reverted, got %+v", undo)
+	}
+	if revert.Journal != JournalUndo || revert.Change.Op != OpUpdate || revert.Change.Item.Status != "started" ||
+		revert.Item == nil || revert.Item.Status != "not-started" {
+		t.Errorf("Expected the update as recorded and the previous item, got %+v", revert)
+	}
+	journal.Record(undo)
+
+	undo, revert, err = journal.Undo()
+	if err != nil {
+		t.Fatalf("Failed to undo: %v", err)
+	}
+	if undo.Op != OpDelete || undo.Item.ItemId != 1 {
+		t.Errorf("Expected the add to be reverted by a delete, got %+v", undo)
+	}
+	if revert.Change.Op != OpAdd || revert.Item != nil {
+		t.Errorf("Expected the add as recorded without an item, got %+v", revert)
+	}
+	journal.Record(undo)
+	if len(journal.Done) != 0 || len(journal.Undone) != 2 {
+		t.Fatalf("Expected 0 changes to undo and 2 to redo, got %d and %d", len(journal.Done), len(journal.Undone))
+	}
+
+	redo, revert, err := journal.Redo()
+	if err != nil {
+		t.Fatalf("Failed to redo: %v", err)
+	}
+	if redo.Op != OpAdd || redo.Journal != JournalRedo {
+		t.Errorf("Expected the add to be redone, got %+v", redo)
+	}
+	if revert.Journal != JournalRedo || revert.Change.Journal != "" || revert.Item == nil || revert.Item.ItemId != 1 {
+		t.Errorf("Expected the add as recorded and the added item, got %+v", revert)
+	}
+	journal.Record(redo)
+
+	journal.Record(Change{Op: OpDelete, Item: before})
+	if _, _, err := journal.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("A new change must clear the changes to redo, got %v", err)
+	}
+}
+
+func TestJournalSize(t *testing.T) {
+	var journal Journal
+	for id := 1; id <= JournalSize+5; id++ {
+		journal.Record(Change{Op: OpAdd, Item: Item{ItemId: id}})
+	}
+	if len(journal.Done) != JournalSize {
+		t.Fatalf("Expected %d changes in the journal, got %d", JournalSize, len(journal.Done))
+	}
+	if journal.Done[0].Item.ItemId != 6 {
+		t.Errorf("Expected the oldest changes to be dropped, first is item %d", journal.Done[0].Item.ItemId)
+	}
+}
+
+func TestRevertSummary(t *testing.T) {
+	tests := []struct {
+		revert   Revert
+		expected string
+	}{
+		{Revert{Journal: JournalUndo, Change: Change{Op: OpDelete, Item: Item{ItemId: 3}}}, "undid the deletion of To-Do Item 3"},
+		{Revert{Journal: JournalUndo, Change: Change{Op: OpAdd, Item: Item{ItemId: 3}}}, "undid the addition of To-Do Item 3"},
+		{Revert{Journal: JournalUndo, Change: Change{Op: OpUpdate, Item: Item{ItemId: 3}}}, "undid the update of To-Do Item 3"},
+		{Revert{Journal: JournalRedo, Change: Change{Op: OpDelete, Item: Item{ItemId: 3}}}, "redid the deletion of To-Do Item 3"},
+	}
+	for _, tc := range tests {
+		if summary := tc.revert.Summary(); summary != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, summary)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package core
+
+// lockFile does nothing, as files cannot be locked here. Writers in other
+// processes are still detected, but only after the fact.
+func lockFile(filePath string) (func(), error) {
+	return func() {}, nil
+}
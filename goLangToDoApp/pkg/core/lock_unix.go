@@ -0,0 +1,26 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package core
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on filePath+LockSuffix, waiting for other
+// writers to release it. The returned function releases the lock.
+func lockFile(filePath string) (func(), error) {
+	lockPath := filePath + LockSuffix
+	file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %w", lockPath, err)
+	}
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("error locking file %s: %w", lockPath, err)
+	}
+	// Closing the file releases the lock.
+	return func() { _ = file.Close() }, nil
+}
@@ -0,0 +1,219 @@
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SortById       = "id"
+	SortByStatus   = "status"
+	SortByDue      = "due"
+	SortByPriority = "priority"
+)
+
+// SortKeys are the accepted values of Query.SortBy.
+var SortKeys = []string{SortById, SortByStatus, SortByDue, SortByPriority}
+
+// Query selects, orders and pages the To-Do items returned by a store. The zero
+// Query returns every item ordered by id.
+type Query struct {
+	// Status keeps items with this status.
+	Status string
+	// Text keeps items whose description contains it, ignoring case.
+	Text string
+	// Tags keeps items carrying all of these tags.
+	Tags []string
+	// DueFrom and DueTo keep items due within the range, both inclusive.
+	// Items without a due date are dropped when either is set. ParseQuery
+	// makes a DueTo given as a date cover that whole day.
+	DueFrom *time.Time
+	DueTo   *time.Time
+
+	// SortBy is one of SortKeys, defaulting to SortById.
+	SortBy string
+	// Desc reverses the sort order.
+	Desc bool
+
+	// Limit caps the number of returned items, zero means no limit.
+	Limit int
+	// Offset skips that many matching items.
+	Offset int
+}
+
+// Page is the result of a Query.
+type Page struct {
+	Items []Item `json:"items"`
+	// Total is the number of items matching the filters, ignoring paging.
+	Total int `json:"total"`
+	// NextOffset is the Offset of the following page, or zero on the last one.
+	NextOffset int `json:"next_offset,omitempty"`
+}
+
+// RunQuery applies query to items without modifying the slice.
+func RunQuery(items []Item, query Query) (Page, error) {
+	err := query.validate()
+	if err != nil {
+		return Page{}, err
+	}
+
+	var matched []Item
+	for _, item := range items {
+		if query.matches(item) {
+			matched = append(matched, item)
+		}
+	}
+
+	slices.SortStableFunc(matched, func(a, b Item) int {
+		// Items without a due date or priority go last in either order.
+		if aNone, bNone := query.unset(a), query.unset(b); aNone != bNone {
+			if aNone {
+				return 1
+			}
+			return -1
+		}
+		c := query.compare(a, b)
+		if c == 0 {
+			c = cmp.Compare(a.ItemId, b.ItemId)
+		}
+		if query.Desc {
+			return -c
+		}
+		return c
+	})
+
+	page := Page{Total: len(matched)}
+	start := min(query.Offset, len(matched))
+	end := len(matched)
+	if query.Limit > 0 && start+query.Limit < end {
+		end = start + query.Limit
+		page.NextOffset = end
+	}
+	page.Items = matched[start:end]
+	return page, nil
+}
+
+// ParseQuery builds a Query from URL query parameters: status, q, tag (may be
+// repeated or comma separated), due_from, due_to, sort, order (asc or desc),
+// limit and offset.
+func ParseQuery(values url.Values) (Query, error) {
+	query := Query{
+		Status: values.Get("status"),
+		Text:   values.Get("q"),
+		SortBy: values.Get("sort"),
+	}
+	for _, tags := range values["tag"] {
+		query.Tags = append(query.Tags, ParseTags(tags)...)
+	}
+
+	for _, param := range []struct {
+		name     string
+		dest     **time.Time
+		endOfDay bool
+	}{{"due_from", &query.DueFrom, false}, {"due_to", &query.DueTo, true}} {
+		if value := values.Get(param.name); value != "" {
+			due, err := ParseDue(value)
+			if err != nil {
+				return Query{}, err
+			}
+			// A date without a time includes everything due on that day.
+			if _, err := time.Parse(DateLayout, strings.TrimSpace(value)); param.endOfDay && err == nil {
+				due = due.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+			*param.dest = &due
+		}
+	}
+
+	switch order := values.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return Query{}, fmt.Errorf("%w: invalid order %q, expected asc or desc", ErrValidation, order)
+	}
+
+	for _, param := range []struct {
+		name string
+		dest *int
+	}{{"limit", &query.Limit}, {"offset", &query.Offset}} {
+		if value := values.Get(param.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return Query{}, fmt.Errorf("%w: invalid %s %q", ErrValidation, param.name, value)
+			}
+			*param.dest = n
+		}
+	}
+
+	return query, query.validate()
+}
+
+func (query Query) validate() error {
+	if query.Status != "" && !slices.Contains(Statuses, query.Status) {
+		return fmt.Errorf("%w: %q", ErrInvalidStatus, query.Status)
+	}
+	if query.SortBy != "" && !slices.Contains(SortKeys, query.SortBy) {
+		return fmt.Errorf("%w: invalid sort key %q, expected one of %s", ErrValidation, query.SortBy, strings.Join(SortKeys, ", "))
+	}
+	if query.Limit < 0 || query.Offset < 0 {
+		return fmt.Errorf("%w: limit and offset must not be negative", ErrValidation)
+	}
+	return nil
+}
+
+func (query Query) matches(item Item) bool {
+	if query.Status != "" && item.Status != query.Status {
+		return false
+	}
+	if query.Text != "" && !strings.Contains(strings.ToLower(item.Description), strings.ToLower(query.Text)) {
+		return false
+	}
+	for _, tag := range query.Tags {
+		if !slices.Contains(item.Tags, tag) {
+			return false
+		}
+	}
+	if query.DueFrom != nil || query.DueTo != nil {
+		if item.Due == nil {
+			return false
+		}
+		if query.DueFrom != nil && item.Due.Before(*query.DueFrom) {
+			return false
+		}
+		if query.DueTo != nil && item.Due.After(*query.DueTo) {
+			return false
+		}
+	}
+	return true
+}
+
+// unset reports whether item has no value for the field sorted by.
+func (query Query) unset(item Item) bool {
+	switch query.SortBy {
+	case SortByDue:
+		return item.Due == nil
+	case SortByPriority:
+		return !slices.Contains(Priorities, item.Priority)
+	}
+	return false
+}
+
+func (query Query) compare(a, b Item) int {
+	switch query.SortBy {
+	case SortByStatus:
+		return cmp.Compare(slices.Index(Statuses, a.Status), slices.Index(Statuses, b.Status))
+	case SortByPriority:
+		return cmp.Compare(slices.Index(Priorities, a.Priority), slices.Index(Priorities, b.Priority))
+	case SortByDue:
+		if a.Due == nil || b.Due == nil {
+			return 0
+		}
+		return a.Due.Compare(*b.Due)
+	}
+	return 0
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func queryItems() []Item {
+	day := func(d int) *time.Time {
+		due := time.Date(2030, time.January, d, 0, 0, 0, 0, time.UTC)
+		return &due
+	}
+	return []Item{
+		{ItemId: 1, Status: "started", Description: "Write report", Priority: "low", Due: day(3), Tags: []string{"work"}},
+		{ItemId: 2, Status: "not-started", Description: "Buy milk", Priority: "high", Tags: []string{"home"}},
+		{ItemId: 3, Status: "completed", Description: "Review REPORT", Priority: "medium", Due: day(1), Tags: []string{"work", "urgent"}},
+		{ItemId: 4, Status: "started", Description: "Call plumber", Due: day(2), Tags: []string{"home"}},
+	}
+}
+
+func ids(items []Item) []int {
+	var result []int
+	for _, item := range items {
+		result = append(result, item.ItemId)
+	}
+	return result
+}
+
+func TestRunQuery(t *testing.T) {
+	from := time.Date(2030, time.January, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query Query
+		want  []int
+	}{
+		{"All", Query{}, []int{1, 2, 3, 4}},
+		{"Status", Query{Status: "started"}, []int{1, 4}},
+		{"Text", Query{Text: "report"}, []int{1, 3}},
+		{"Tags", Query{Tags: []string{"work", "urgent"}}, []int{3}},
+		{"DueFrom", Query{DueFrom: &from}, []int{1, 4}},
+		{"DueTo", Query{DueTo: &from}, []int{3, 4}},
+		{"SortStatus", Query{SortBy: SortByStatus}, []int{2, 1, 4, 3}},
+		{"SortDue", Query{SortBy: SortByDue}, []int{3, 4, 1, 2}},
+		{"SortDueDesc", Query{SortBy: SortByDue, Desc: true}, []int{1, 4, 3, 2}},
+		{"SortPriority", Query{SortBy: SortByPriority}, []int{1, 3, 2, 4}},
+		{"SortPriorityDesc", Query{SortBy: SortByPriority, Desc: true}, []int{2, 3, 1, 4}},
+		{"Limit", Query{Limit: 2}, []int{1, 2}},
+		{"Offset", Query{Limit: 2, Offset: 3}, []int{4}},
+		{"OffsetPastEnd", Query{Offset: 10}, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := RunQuery(queryItems(), tc.query)
+			if err != nil {
+				t.Fatalf("RunQuery failed: %v", err)
+			}
+			got := ids(page.Items)
+			if len(got) != len(tc.want) {
+				t.Fatalf("Expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("Expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRunQueryPaging(t *testing.T) {
+	page, err := RunQuery(queryItems(), Query{Limit: 3})
+	if err != nil {
+		t.Fatalf("RunQuery failed: %v", err)
+	}
+	if page.Total != 4 || page.NextOffset != 3 {
+		t.Errorf("Unexpected paging %d/%d", page.Total, page.NextOffset)
+	}
+
+	page, err = RunQuery(queryItems(), Query{Limit: 3, Offset: page.NextOffset})
+	if err != nil {
+		t.Fatalf("RunQuery failed: %v", err)
+	}
+	if len(page.Items) != 1 || page.NextOffset != 0 {
+		t.Errorf("Expected last page with 1 item, got %d items, next %d", len(page.Items), page.NextOffset)
+	}
+}
+
+func TestRunQueryInvalid(t *testing.T) {
+	for _, query := range []Query{{Status: "unknown"}, {SortBy: "colour"}, {Limit: -1}} {
+		if _, err := RunQuery(queryItems(), query); err == nil {
+			t.Errorf("Expected error for %+v", query)
+		}
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	values, _ := url.ParseQuery("status=started&q=report&tag=work&tag=a,b&due_from=2030-01-01&sort=due&order=desc&limit=5&offset=10")
+	query, err := ParseQuery(values)
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %v", err)
+	}
+	if query.Status != "started" || query.Text != "report" || len(query.Tags) != 3 ||
+		query.DueFrom == nil || query.SortBy != SortByDue || !query.Desc || query.Limit != 5 || query.Offset != 10 {
+		t.Errorf("Unexpected query %+v", query)
+	}
+
+	values, _ = url.ParseQuery("due_to=2030-01-02")
+	query, err = ParseQuery(values)
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %v", err)
+	}
+	afternoon := time.Date(2030, time.January, 2, 15, 0, 0, 0, time.UTC)
+	if query.DueTo == nil || query.DueTo.Before(afternoon) || !query.DueTo.Before(afternoon.Add(9*time.Hour)) {
+		t.Errorf("Expected due_to to cover the whole day, got %v", query.DueTo)
+	}
+
+	for _, raw := range []string{"order=up", "limit=many", "due_to=tomorrow", "sort=colour"} {
+		values, _ := url.ParseQuery(raw)
+		if _, err := ParseQuery(values); err == nil {
+			t.Errorf("Expected error for %q", raw)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// Item is a single entry of a To-Do list, shared by every Store implementation.
+// Every field after Description is optional so data files written before they
+// existed still load.
+type Item struct {
+	ItemId      int        `json:"id"`
+	UUID        string     `json:"uuid,omitempty"`
+	Status      string     `json:"status"`
+	Description string     `json:"description"`
+	Priority    string     `json:"priority,omitempty"`
+	Due         *time.Time `json:"due,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Data is the persisted state of a store: its items and the id sequence.
+type Data struct {
+	// NextId is the id given to the next added item. It only ever grows, so
+	// ids of deleted items are never reused.
+	NextId int    `json:"next_id"`
+	Items  []Item `json:"items"`
+	// Journal holds the last changes, so they can be undone and redone.
+	Journal
+	// LogSeq is the sequence number of the last write-ahead log record the
+	// data contains, see LogBackend.
+	LogSeq int `json:"log_seq,omitempty"`
+}
+
+// ItemPatch lists the fields of an Item to change. Nil fields are left
+// unchanged, a zero Due clears the due date and an empty non-nil Tags clears
+// the tags.
+type ItemPatch struct {
+	Status      *string
+	Description *string
+	Priority    *string
+	Due         *time.Time
+	Tags        []string
+}
+
+// Store is the set of operations every To-Do store implementation provides.
+// Each call takes the context of the request it serves, so the store logs
+// under the same trace id.
+type Store interface {
+	GetAllToDoItems(ctx context.Context) ([]Item, error)
+	// QueryToDoItems returns the items selected by query.
+	QueryToDoItems(ctx context.Context, query Query) (Page, error)
+	AddNewToDoItem(ctx context.Context, desc string) error
+	// AddToDoItem adds a new item using the description, priority, due date
+	// and tags of item, and returns it with its id and timestamps set.
+	AddToDoItem(ctx context.Context, item Item) (Item, error)
+	UpdateToDoItem(ctx context.Context, id int, status string, desc string) error
+	// PatchToDoItem applies patch to the item with id and returns the result.
+	PatchToDoItem(ctx context.Context, id int, patch ItemPatch) (Item, error)
+	DeleteToDoItem(ctx context.Context, id int) error
+	// Undo reverts the last change still in the journal and returns the
+	// change as recorded with the resulting item. Redo makes the last undone
+	// change again. They fail with ErrNothingToUndo and ErrNothingToRedo when
+	// there is nothing left.
+	Undo(ctx context.Context) (Revert, error)
+	Redo(ctx context.Context) (Revert, error)
+	// Subscribe returns a channel receiving the changes made to the store
+	// from now on, see Changes.Subscribe.
+	Subscribe(ctx context.Context) <-chan Change
+	// Close flushes the store to its backend and releases it. Later calls
+	// fail with ErrClosed, closing again does nothing.
+	Close(ctx context.Context) error
+}
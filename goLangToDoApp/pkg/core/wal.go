@@ -0,0 +1,266 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// LogSuffix is appended to the snapshot file name to get the write-ahead log.
+const LogSuffix = ".log"
+
+// DefaultCompactEvery is the number of log records after which the log is
+// compacted into the snapshot.
+const DefaultCompactEvery = 100
+
+// truncateLog empties the log after a compaction. Tests replace it to
+// simulate a crash before the truncate.
+var truncateLog = os.Truncate
+
+// LogBackend appends every change as a JSON line to a write-ahead log and
+// periodically compacts the log into a snapshot in the JSON file format.
+type LogBackend struct {
+	// CompactEvery is the number of log records after which the log is
+	// compacted. Zero or less disables compaction.
+	CompactEvery int
+
+	filePath string
+	records  int
+	// seq is the sequence number of the last record written.
+	seq int
+}
+
+// logRecord is a line of the write-ahead log. Records are numbered so those
+// already contained in the snapshot are skipped on replay.
+type logRecord struct {
+	Seq int `json:"seq"`
+	Change
+}
+
+// NewLogBackend initializes a LogBackend with the snapshot at filePath and the
+// log next to it.
+func NewLogBackend(filePath string) *LogBackend {
+	return &LogBackend{
+		CompactEvery: DefaultCompactEvery,
+		filePath:     filePath,
+	}
+}
+
+// Load reads the snapshot and replays the log on top of it. A torn record at
+// the end of the log, left behind by a crash during an append, is discarded.
+func (backend *LogBackend) Load() (Data, error) {
+	// The lock keeps a record another writer is appending from being taken
+	// for a torn one.
+	unlock, err := lockFile(backend.filePath)
+	if err != nil {
+		return Data{}, err
+	}
+	defer unlock()
+
+	data, err := LoadData(backend.filePath)
+	if err != nil {
+		return Data{}, err
+	}
+
+	logPath := backend.filePath + LogSuffix
+	logData, err := os.ReadFile(logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			backend.records = 0
+			backend.seq = data.LogSeq
+			return data, nil
+		}
+		return Data{}, fmt.Errorf("error reading file %s: %w", logPath, err)
+	}
+
+	records := 0
+	offset := 0
+	for offset < len(logData) {
+		end := bytes.IndexByte(logData[offset:], '\n')
+		if end < 0 {
+			// Torn write, the record never completed.
+			slog.Warn("Discarding incomplete write-ahead log record.", "file", logPath)
+			err := os.Truncate(logPath, int64(offset))
+			if err != nil {
+				return Data{}, fmt.Errorf("error truncating file %s: %w", logPath, err)
+			}
+			break
+		}
+
+		var record logRecord
+		err := json.Unmarshal(logData[offset:offset+end], &record)
+		if err != nil {
+			return Data{}, fmt.Errorf("error unmarshalling write-ahead log record %d: %w", records+1, err)
+		}
+		records++
+		offset += end + 1
+		if record.Seq <= data.LogSeq {
+			continue
+		}
+		data.Items = record.Change.Apply(data.Items)
+		data.Journal.Record(record.Change)
+		// Every record carries the item id, so the sequence is recovered
+		// from the log without separate records.
+		data.NextId = max(data.NextId, record.Item.ItemId+1)
+		data.LogSeq = record.Seq
+	}
+
+	backend.records = records
+	backend.seq = data.LogSeq
+	return data, nil
+}
+
+// Save appends the change to the log and compacts the log once it holds
+// CompactEvery records. It fails with ErrConflict when another writer has
+// written to the log since it was loaded.
+func (backend *LogBackend) Save(data Data, change Change) error {
+	unlock, err := lockFile(backend.filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = backend.checkSeq()
+	if err != nil {
+		return err
+	}
+	err = backend.appendRecord(change)
+	if err != nil {
+		return err
+	}
+	backend.seq++
+	backend.records++
+
+	if backend.CompactEvery > 0 && backend.records >= backend.CompactEvery {
+		return backend.compact(data)
+	}
+	return nil
+}
+
+// Flush compacts the log into the snapshot unless the log is empty.
+func (backend *LogBackend) Flush(data Data) error {
+	if backend.records == 0 {
+		return nil
+	}
+	unlock, err := lockFile(backend.filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = backend.checkSeq()
+	if errors.Is(err, ErrConflict) {
+		// Compacting data would drop the records of the other writer. The
+		// log holds every change, so it is left for the next compaction.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return backend.compact(data)
+}
+
+// Compact writes data as the new snapshot and empties the log. It fails with
+// ErrConflict when another writer has written to the log since it was loaded.
+func (backend *LogBackend) Compact(data Data) error {
+	unlock, err := lockFile(backend.filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = backend.checkSeq()
+	if err != nil {
+		return err
+	}
+	return backend.compact(data)
+}
+
+// compact is Compact with the lock held.
+func (backend *LogBackend) compact(data Data) error {
+	data.LogSeq = backend.seq
+	err := SaveData(backend.filePath, data)
+	if err != nil {
+		return err
+	}
+
+	// A crash before the truncate leaves the log next to a snapshot which
+	// already contains it, its records are skipped by their sequence number.
+	logPath := backend.filePath + LogSuffix
+	err = truncateLog(logPath, 0)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error truncating file %s: %w", logPath, err)
+	}
+	backend.records = 0
+	return nil
+}
+
+// checkSeq fails with ErrConflict when the last record written to the log is
+// not the last one the backend has seen.
+func (backend *LogBackend) checkSeq() error {
+	logPath := backend.filePath + LogSuffix
+	logData, err := os.ReadFile(logPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading file %s: %w", logPath, err)
+	}
+
+	// A torn record at the end never completed, Load discards it.
+	logData = logData[:bytes.LastIndexByte(logData, '\n')+1]
+	seq := 0
+	if len(logData) > 0 {
+		start := bytes.LastIndexByte(logData[:len(logData)-1], '\n') + 1
+		var record logRecord
+		err = json.Unmarshal(logData[start:], &record)
+		if err != nil {
+			return fmt.Errorf("error unmarshalling write-ahead log record: %w", err)
+		}
+		seq = record.Seq
+	} else {
+		// The log is empty after a compaction, the snapshot has the sequence.
+		data, err := LoadData(backend.filePath)
+		if err != nil {
+			return err
+		}
+		seq = data.LogSeq
+	}
+
+	if seq != backend.seq {
+		return changedError(backend.filePath)
+	}
+	return nil
+}
+
+func (backend *LogBackend) appendRecord(change Change) error {
+	data, err := json.Marshal(logRecord{Seq: backend.seq + 1, Change: change})
+	if err != nil {
+		return fmt.Errorf("error marshalling write-ahead log record: %w", err)
+	}
+
+	logPath := backend.filePath + LogSuffix
+	file, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %w", logPath, err)
+	}
+
+	info, err := file.Stat()
+	if err == nil {
+		_, err = file.Write(append(data, '\n'))
+		if err != nil {
+			// Drop the torn record so the next append starts on a clean line.
+			_ = file.Truncate(info.Size())
+		}
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error appending to file %s: %w", logPath, err)
+	}
+	return nil
+}
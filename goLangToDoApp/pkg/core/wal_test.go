@@ -0,0 +1,199 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func saveChanges(t *testing.T, backend Backend, changes ...Change) Data {
+	t.Helper()
+	var data Data
+	for _, change := range changes {
+		data.Items = change.Apply(data.Items)
+		data.NextId = max(data.NextId, change.Item.ItemId+1)
+		if err := backend.Save(data, change); err != nil {
+			t.Fatalf("Failed to save change: %v", err)
+		}
+	}
+	return data
+}
+
+func TestLogBackendReplay(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	backend := NewLogBackend(filePath)
+
+	saveChanges(t, backend,
+		Change{Op: OpAdd, Item: Item{ItemId: 1, Status: "not-started", Description: "first"}},
+		Change{Op: OpAdd, Item: Item{ItemId: 2, Status: "not-started", Description: "second"}},
+		Change{Op: OpUpdate, Item: Item{ItemId: 1, Status: "started", Description: "first"}},
+		Change{Op: OpDelete, Item: Item{ItemId: 2}},
+	)
+
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Errorf("Snapshot must not be written before compaction")
+	}
+
+	data, err := NewLogBackend(filePath).Load()
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if len(data.Items) != 1 || data.Items[0].ItemId != 1 || data.Items[0].Status != "started" {
+		t.Errorf("Unexpected replayed items %v", data.Items)
+	}
+	if data.NextId != 3 {
+		t.Errorf("Deleted ids must not be reused, expected next id 3, got %d", data.NextId)
+	}
+}
+
+func TestLogBackendReplayJournal(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	backend := NewLogBackend(filePath)
+
+	first := Item{ItemId: 1, Status: "not-started", Description: "first"}
+	saveChanges(t, backend,
+		Change{Op: OpAdd, Item: first},
+		Change{Op: OpAdd, Item: Item{ItemId: 2, Status: "not-started", Description: "second"}},
+		Change{Op: OpDelete, Item: first},
+		Change{Op: OpAdd, Item: first, Journal: JournalUndo},
+	)
+
+	data, err := NewLogBackend(filePath).Load()
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if len(data.Done) != 2 || len(data.Undone) != 1 || data.Undone[0].Op != OpDelete {
+		t.Errorf("Unexpected replayed journal %+v", data.Journal)
+	}
+}
+
+func TestLogBackendCompact(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	backend := NewLogBackend(filePath)
+	backend.CompactEvery = 3
+
+	saveChanges(t, backend,
+		Change{Op: OpAdd, Item: Item{ItemId: 1, Description: "first"}},
+		Change{Op: OpAdd, Item: Item{ItemId: 2, Description: "second"}},
+		Change{Op: OpAdd, Item: Item{ItemId: 3, Description: "third"}},
+	)
+
+	snapshot, err := LoadData(filePath)
+	if err != nil || len(snapshot.Items) != 3 {
+		t.Errorf("Expected compacted snapshot with 3 items, got %v (%v)", snapshot, err)
+	}
+	info, err := os.Stat(filePath + LogSuffix)
+	if err != nil || info.Size() != 0 {
+		t.Errorf("Expected empty log after compaction")
+	}
+
+	// Replaying a log on top of a snapshot that already contains it, as after
+	// a crash between snapshot and truncate, must not duplicate items.
+	log := NewLogBackend(filePath + ".replay")
+	saveChanges(t, log, Change{Op: OpAdd, Item: Item{ItemId: 3, Description: "third"}})
+	raw, _ := os.ReadFile(filePath + ".replay" + LogSuffix)
+	if err := os.WriteFile(filePath+LogSuffix, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := NewLogBackend(filePath).Load()
+	if err != nil || len(data.Items) != 3 {
+		t.Errorf("Expected 3 items after idempotent replay, got %v (%v)", data.Items, err)
+	}
+}
+
+func TestLogBackendCrashBeforeTruncate(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	backend := NewLogBackend(filePath)
+	backend.CompactEvery = 0
+
+	first := Item{ItemId: 1, Status: "not-started", Description: "first"}
+	data := saveChanges(t, backend,
+		Change{Op: OpAdd, Item: first},
+		Change{Op: OpAdd, Item: Item{ItemId: 2, Status: "not-started", Description: "second"}},
+		Change{Op: OpDelete, Item: first},
+	)
+
+	// Simulate a crash between writing the snapshot and truncating the log.
+	truncateLog = func(string, int64) error { return errors.New("crashed") }
+	t.Cleanup(func() { truncateLog = os.Truncate })
+	if err := backend.Compact(data); err == nil {
+		t.Fatalf("Expected the simulated crash to fail the compaction")
+	}
+	truncateLog = os.Truncate
+
+	backend = NewLogBackend(filePath)
+	reloaded, err := backend.Load()
+	if err != nil || len(reloaded.Items) != 1 || reloaded.Items[0].ItemId != 2 {
+		t.Fatalf("Expected only the second item after replay, got %v (%v)", reloaded.Items, err)
+	}
+	if backend.seq != 3 {
+		t.Errorf("Expected the log records up to 3 to be in the snapshot, got %d", backend.seq)
+	}
+
+	// Records appended after the reload must not be skipped.
+	saveChanges(t, backend, Change{Op: OpAdd, Item: Item{ItemId: 3, Description: "third"}})
+	reloaded, err = NewLogBackend(filePath).Load()
+	if err != nil || len(reloaded.Items) != 2 {
+		t.Errorf("Expected the new record to be replayed, got %v (%v)", reloaded.Items, err)
+	}
+}
+
+func TestLogBackendTornRecord(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	saveChanges(t, NewLogBackend(filePath),
+		Change{Op: OpAdd, Item: Item{ItemId: 1, Description: "first"}},
+	)
+
+	// Simulate a crash in the middle of appending the second record.
+	file, err := os.OpenFile(filePath+LogSuffix, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.WriteString(`{"op":"add","item":{"id":2,`)
+	_ = file.Close()
+
+	backend := NewLogBackend(filePath)
+	data, err := backend.Load()
+	if err != nil || len(data.Items) != 1 {
+		t.Fatalf("Expected torn record to be discarded, got %v (%v)", data.Items, err)
+	}
+
+	// The next append must start on a clean line.
+	saveChanges(t, backend, Change{Op: OpAdd, Item: Item{ItemId: 2, Description: "second"}})
+	reloaded, err := NewLogBackend(filePath).Load()
+	if err != nil || len(reloaded.Items) != 2 {
+		t.Errorf("Expected 2 items after append, got %v (%v)", reloaded.Items, err)
+	}
+}
+
+func TestLogBackendCorruptRecord(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	if err := os.WriteFile(filePath+LogSuffix, []byte("not json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewLogBackend(filePath).Load(); err == nil {
+		t.Errorf("Expected error for corrupt log record")
+	}
+}
+
+func TestLogBackendFlush(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	backend := NewLogBackend(filePath)
+	data := saveChanges(t, backend,
+		Change{Op: OpAdd, Item: Item{ItemId: 1, Description: "first"}},
+	)
+
+	if err := backend.Flush(data); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	snapshot, err := LoadData(filePath)
+	if err != nil || len(snapshot.Items) != 1 {
+		t.Errorf("Expected flushed snapshot with 1 item, got %v (%v)", snapshot, err)
+	}
+	info, err := os.Stat(filePath + LogSuffix)
+	if err != nil || info.Size() != 0 {
+		t.Errorf("Expected empty log after flush")
+	}
+}
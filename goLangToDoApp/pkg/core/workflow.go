@@ -0,0 +1,150 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Workflow is the set of statuses an item can have and the allowed moves
+// between them.
+type Workflow struct {
+	// Statuses lists every status, the first one is given to new items.
+	Statuses []string `json:"statuses"`
+	// Completed lists the statuses that mark an item as done and set its
+	// completed timestamp. Defaults to the last status.
+	Completed []string `json:"completed,omitempty"`
+	// Transitions maps a status to the statuses it may move to. Statuses
+	// without an entry may move to any status, an empty list makes a status
+	// final. No transitions at all allows every move.
+	Transitions map[string][]string `json:"transitions,omitempty"`
+}
+
+// DefaultWorkflow allows any move between not-started, started and completed.
+var DefaultWorkflow = Workflow{
+	Statuses:  []string{"not-started", "started", "completed"},
+	Completed: []string{"completed"},
+}
+
+// The active workflow. SetWorkflow replaces it and is meant to be called once
+// at startup, before any store is used.
+var (
+	Statuses          = slices.Clone(DefaultWorkflow.Statuses)
+	CompletedStatuses = slices.Clone(DefaultWorkflow.Completed)
+	Transitions       map[string][]string
+)
+
+// TransitionError is returned when the workflow does not allow an item to
+// move from one status to another.
+type TransitionError struct {
+	ItemId  int
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (err *TransitionError) Error() string {
+	allowed := "none, the status is final"
+	if len(err.Allowed) > 0 {
+		allowed = strings.Join(err.Allowed, ", ")
+	}
+	return fmt.Sprintf("To-Do Item %d cannot move from %q to %q, allowed: %s", err.ItemId, err.From, err.To, allowed)
+}
+
+// CurrentWorkflow returns a copy of the active workflow.
+func CurrentWorkflow() Workflow {
+	return Workflow{
+		Statuses:    slices.Clone(Statuses),
+		Completed:   slices.Clone(CompletedStatuses),
+		Transitions: maps.Clone(Transitions),
+	}
+}
+
+// SetWorkflow validates workflow and makes it the active workflow.
+func SetWorkflow(workflow Workflow) error {
+	err := workflow.validate()
+	if err != nil {
+		return err
+	}
+
+	Statuses = slices.Clone(workflow.Statuses)
+	CompletedStatuses = slices.Clone(workflow.Completed)
+	if len(CompletedStatuses) == 0 {
+		CompletedStatuses = []string{Statuses[len(Statuses)-1]}
+	}
+	Transitions = maps.Clone(workflow.Transitions)
+	return nil
+}
+
+// ReadWorkflow reads a workflow from a JSON file such as:
+//
+//	{
+//		"statuses": ["not-started", "started", "blocked", "completed"],
+//		"completed": ["completed"],
+//		"transitions": {"not-started": ["started"], "completed": []}
+//	}
+func ReadWorkflow(filePath string) (Workflow, error) {
+	byteValue, err := os.ReadFile(filePath)
+	if err != nil {
+		return Workflow{}, fmt.Errorf("error reading file %s: %w", filePath, err)
+	}
+
+	var workflow Workflow
+	err = json.Unmarshal(byteValue, &workflow)
+	if err != nil {
+		return Workflow{}, fmt.Errorf("error unmarshalling workflow %s: %w", filePath, err)
+	}
+	return workflow, workflow.validate()
+}
+
+// CheckTransition returns a *TransitionError when the active workflow does not
+// allow the item to move from its status to status.
+func CheckTransition(item Item, status string) error {
+	if item.Status == status || Transitions == nil {
+		return nil
+	}
+	allowed, ok := Transitions[item.Status]
+	if !ok || slices.Contains(allowed, status) {
+		return nil
+	}
+	return &TransitionError{ItemId: item.ItemId, From: item.Status, To: status, Allowed: slices.Clone(allowed)}
+}
+
+// IsCompleted reports whether status marks an item as done.
+func IsCompleted(status string) bool {
+	return slices.Contains(CompletedStatuses, status)
+}
+
+func (workflow Workflow) validate() error {
+	if len(workflow.Statuses) == 0 {
+		return errors.New("workflow must have at least one status")
+	}
+	for index, status := range workflow.Statuses {
+		if strings.TrimSpace(status) == "" || strings.ContainsAny(status, " \t,") {
+			return fmt.Errorf("workflow status %q must be a single word", status)
+		}
+		if slices.Contains(workflow.Statuses[:index], status) {
+			return fmt.Errorf("workflow status %q is listed twice", status)
+		}
+	}
+	for _, status := range workflow.Completed {
+		if !slices.Contains(workflow.Statuses, status) {
+			return fmt.Errorf("completed status %q is not a workflow status", status)
+		}
+	}
+	for from, targets := range workflow.Transitions {
+		if !slices.Contains(workflow.Statuses, from) {
+			return fmt.Errorf("transition from unknown status %q", from)
+		}
+		for _, to := range targets {
+			if !slices.Contains(workflow.Statuses, to) {
+				return fmt.Errorf("transition from %q to unknown status %q", from, to)
+			}
+		}
+	}
+	return nil
+}
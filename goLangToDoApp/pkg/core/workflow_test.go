@@ -0,0 +1,114 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setTestWorkflow(t *testing.T, workflow Workflow) {
+	t.Helper()
+	if err := SetWorkflow(workflow); err != nil {
+		t.Fatalf("Failed to set workflow: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = SetWorkflow(DefaultWorkflow)
+	})
+}
+
+var reviewWorkflow = Workflow{
+	Statuses:  []string{"not-started", "started", "in-review", "blocked", "completed", "cancelled"},
+	Completed: []string{"completed", "cancelled"},
+	Transitions: map[string][]string{
+		"not-started": {"started", "cancelled"},
+		"started":     {"in-review", "blocked", "cancelled"},
+		"in-review":   {"started", "completed"},
+		"completed":   {},
+	},
+}
+
+func TestApplyPatchTransitions(t *testing.T) {
+	setTestWorkflow(t, reviewWorkflow)
+
+	item, err := NewItem(1, Item{Description: "Task"}, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create item: %v", err)
+	}
+
+	move := func(status string) error {
+		return ApplyPatch(&item, ItemPatch{Status: &status}, time.Now())
+	}
+
+	err = move("completed")
+	var transitionErr *TransitionError
+	if !errors.As(err, &transitionErr) {
+		t.Fatalf("Expected *TransitionError, got %v", err)
+	}
+	if transitionErr.From != "not-started" || transitionErr.To != "completed" || len(transitionErr.Allowed) != 2 {
+		t.Errorf("Unexpected transition error %+v", transitionErr)
+	}
+	if item.Status != "not-started" {
+		t.Errorf("Rejected transition must not change the status, got %q", item.Status)
+	}
+
+	for _, status := range []string{"started", "in-review", "completed"} {
+		if err := move(status); err != nil {
+			t.Fatalf("Failed to move to %q: %v", status, err)
+		}
+	}
+	if item.CompletedAt == nil {
+		t.Errorf("Completed timestamp was not set")
+	}
+
+	if err := move("started"); !errors.As(err, &transitionErr) {
+		t.Errorf("Expected final status to reject moves, got %v", err)
+	}
+	if err := move("completed"); err != nil {
+		t.Errorf("Staying in the same status must be allowed, got %v", err)
+	}
+}
+
+func TestDefaultWorkflowAllowsAnyMove(t *testing.T) {
+	item := Item{ItemId: 1, Status: "completed"}
+	if err := CheckTransition(item, "not-started"); err != nil {
+		t.Errorf("Default workflow must allow any move, got %v", err)
+	}
+}
+
+func TestSetWorkflowInvalid(t *testing.T) {
+	for _, workflow := range []Workflow{
+		{},
+		{Statuses: []string{"a", "a"}},
+		{Statuses: []string{"in review"}},
+		{Statuses: []string{"a"}, Completed: []string{"b"}},
+		{Statuses: []string{"a"}, Transitions: map[string][]string{"a": {"b"}}},
+	} {
+		if err := SetWorkflow(workflow); err == nil {
+			_ = SetWorkflow(DefaultWorkflow)
+			t.Errorf("Expected error for workflow %+v", workflow)
+		}
+	}
+	if Statuses[0] != DefaultWorkflow.Statuses[0] {
+		t.Errorf("Invalid workflow must not replace the active one")
+	}
+}
+
+func TestReadWorkflow(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "workflow.json")
+	config := `{"statuses": ["todo", "doing", "done"], "transitions": {"done": []}}`
+	if err := os.WriteFile(filePath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workflow, err := ReadWorkflow(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read workflow: %v", err)
+	}
+	setTestWorkflow(t, workflow)
+
+	if Statuses[0] != "todo" || !IsCompleted("done") || IsCompleted("doing") {
+		t.Errorf("Unexpected active workflow %+v", CurrentWorkflow())
+	}
+}
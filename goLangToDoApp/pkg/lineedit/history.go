@@ -0,0 +1,94 @@
+package lineedit
+
+import (
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultHistorySize is the number of lines kept by a History.
+const DefaultHistorySize = 1000
+
+// History keeps the entered lines, oldest first. Lines are appended to its
+// file as they are added, so they survive the session.
+type History struct {
+	filePath string
+	size     int
+	entries  []string
+}
+
+// LoadHistory reads the history file at filePath, keeping the last size
+// lines. A missing file starts an empty history and an empty filePath keeps
+// the history in memory only. The history is usable even when reading fails.
+func LoadHistory(filePath string, size int) (*History, error) {
+	history := &History{filePath: filePath, size: max(size, 1)}
+	if filePath == "" {
+		return history, nil
+	}
+	byteValue, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return history, fmt.Errorf("error reading history file %s: %w", filePath, err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(byteValue), "\n"), "\n")
+	lines = slices.DeleteFunc(lines, func(line string) bool { return line == "" })
+	if len(lines) <= history.size {
+		history.entries = lines
+		return history, nil
+	}
+	// Drop the lines past the size from the file too, so it does not grow
+	// forever.
+	history.entries = slices.Clone(lines[len(lines)-history.size:])
+	err = core.WriteFileAtomic(filePath, []byte(strings.Join(history.entries, "\n")+"\n"), 0600)
+	if err != nil {
+		return history, fmt.Errorf("error trimming history file %s: %w", filePath, err)
+	}
+	return history, nil
+}
+
+// Add appends line to the history and its file. Blank lines and repeats of
+// the last line are skipped.
+func (history *History) Add(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.Contains(line, "\n") ||
+		(len(history.entries) > 0 && history.entries[len(history.entries)-1] == line) {
+		return nil
+	}
+	history.entries = append(history.entries, line)
+	if len(history.entries) > history.size {
+		history.entries = slices.Delete(history.entries, 0, len(history.entries)-history.size)
+	}
+	if history.filePath == "" {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(history.filePath), 0755)
+	if err != nil {
+		return fmt.Errorf("error saving history file %s: %w", history.filePath, err)
+	}
+	file, err := os.OpenFile(history.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error saving history file %s: %w", history.filePath, err)
+	}
+	_, err = file.WriteString(line + "\n")
+	err = errors.Join(err, file.Close())
+	if err != nil {
+		return fmt.Errorf("error saving history file %s: %w", history.filePath, err)
+	}
+	return nil
+}
+
+// Entries returns the lines of the history, oldest first.
+func (history *History) Entries() []string {
+	if history == nil {
+		return nil
+	}
+	return slices.Clone(history.entries)
+}
@@ -0,0 +1,46 @@
+package lineedit
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "dir", "history")
+	history, err := LoadHistory(filePath, 3)
+	if err != nil || len(history.Entries()) != 0 {
+		t.Fatalf("Expected an empty history, got %q, error %v", history.Entries(), err)
+	}
+	for _, line := range []string{"list", "  ", "add milk", "add milk", " delete 3 ", "list"} {
+		err = history.Add(line)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	want := []string{"add milk", "delete 3", "list"}
+	if !slices.Equal(history.Entries(), want) {
+		t.Errorf("Expected %q, got %q", want, history.Entries())
+	}
+
+	// The file keeps every added line until the next load trims it.
+	byteValue, _ := os.ReadFile(filePath)
+	if string(byteValue) != "list\nadd milk\ndelete 3\nlist\n" {
+		t.Errorf("Unexpected history file %q", byteValue)
+	}
+	loaded, err := LoadHistory(filePath, 3)
+	if err != nil || !slices.Equal(loaded.Entries(), want) {
+		t.Errorf("Expected %q after loading, got %q, error %v", want, loaded.Entries(), err)
+	}
+	byteValue, _ = os.ReadFile(filePath)
+	if string(byteValue) != strings.Join(want, "\n")+"\n" {
+		t.Errorf("Expected the file to be trimmed, got %q", byteValue)
+	}
+
+	var none *History
+	if none.Entries() != nil {
+		t.Errorf("Expected no entries for a nil history")
+	}
+}
@@ -0,0 +1,494 @@
+// Package lineedit reads lines from a terminal with editing, history and
+// completion, using only the standard library. The usual readline keys are
+// supported: arrows, Home/End and Delete, Ctrl-A/E/B/F to move, Ctrl-K/U/W to
+// cut, Ctrl-P/N or Up/Down for the history, Ctrl-R to search it and Tab to
+// complete. When the input is not a terminal, lines are read as they come.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+// Completer returns the candidates for the word before the cursor, given the
+// text before the cursor. Words are separated by spaces and every candidate
+// replaces the whole word.
+type Completer func(before string) []string
+
+// Editor reads lines from its input.
+type Editor struct {
+	// Complete completes the word before the cursor on Tab, nil disables
+	// completion.
+	Complete Completer
+	// History is browsed with Up/Down and searched with Ctrl-R, nil disables
+	// both. The editor does not add lines, see History.Add.
+	History *History
+
+	in     io.Reader
+	reader *bufio.Reader
+	out    io.Writer
+}
+
+// New returns an Editor reading from in and echoing to out. Lines are only
+// edited when both are terminals.
+func New(in io.Reader, out io.Writer) *Editor {
+	return &Editor{in: in, reader: bufio.NewReader(in), out: out}
+}
+
+// IsTerminal reports whether file is a terminal.
+func IsTerminal(file *os.File) bool {
+	return isTerminal(file)
+}
+
+// Terminal reports whether the editor reads from and echoes to a terminal.
+// Lines are edited where the terminal supports it.
+func (editor *Editor) Terminal() bool {
+	_, ok := editor.terminal()
+	return ok
+}
+
+func (editor *Editor) terminal() (uintptr, bool) {
+	in, ok := editor.in.(*os.File)
+	if !ok || !isTerminal(in) {
+		return 0, false
+	}
+	out, ok := editor.out.(*os.File)
+	if !ok || !isTerminal(out) {
+		return 0, false
+	}
+	return in.Fd(), true
+}
+
+// ReadLine shows prompt and returns the entered line without its newline. It
+// returns io.EOF at the end of the input or on Ctrl-D on an empty line, and
+// ErrInterrupted on Ctrl-C.
+func (editor *Editor) ReadLine(prompt string) (string, error) {
+	fd, ok := editor.terminal()
+	if ok {
+		restore, err := makeRaw(fd)
+		if err == nil {
+			defer restore()
+			return editor.edit(prompt)
+		}
+	}
+
+	fmt.Fprint(editor.out, prompt)
+	line, err := editor.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Control keys.
+const (
+	keyCtrlA     = 'A' - '@'
+	keyCtrlB     = 'B' - '@'
+	keyCtrlC     = 'C' - '@'
+	keyCtrlD     = 'D' - '@'
+	keyCtrlE     = 'E' - '@'
+	keyCtrlF     = 'F' - '@'
+	keyCtrlG     = 'G' - '@'
+	keyCtrlH     = 'H' - '@'
+	keyTab       = 'I' - '@'
+	keyCtrlK     = 'K' - '@'
+	keyCtrlL     = 'L' - '@'
+	keyEnter     = 'M' - '@'
+	keyNewline   = 'J' - '@'
+	keyCtrlN     = 'N' - '@'
+	keyCtrlP     = 'P' - '@'
+	keyCtrlR     = 'R' - '@'
+	keyCtrlU     = 'U' - '@'
+	keyCtrlW     = 'W' - '@'
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// Keys sent as escape sequences, mapped outside the Unicode range.
+const (
+	keyUp rune = unicode.MaxRune + 1 + iota
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyWordLeft
+	keyWordRight
+	keyUnknown
+)
+
+// state is the line being edited.
+type state struct {
+	prompt string
+	buf    []rune
+	pos    int
+	// history is a copy of the history with the edited line last, so lines
+	// recalled and changed are kept while browsing.
+	history []string
+	index   int
+	// tabbed is set after a Tab that could not complete further, so a
+	// second Tab lists the candidates.
+	tabbed bool
+}
+
+func (s *state) set(line string) {
+	s.buf = []rune(line)
+	s.pos = len(s.buf)
+}
+
+// edit reads keys until a line is entered.
+func (editor *Editor) edit(prompt string) (string, error) {
+	s := &state{prompt: prompt, history: append(editor.History.Entries(), "")}
+	s.index = len(s.history) - 1
+	editor.refresh(s)
+	for {
+		key, err := editor.readKey()
+		if err != nil {
+			if err == io.EOF && len(s.buf) > 0 {
+				break
+			}
+			return "", err
+		}
+
+		tabbed := false
+		switch key {
+		case keyEnter, keyNewline:
+			fmt.Fprint(editor.out, "\r\n")
+			return string(s.buf), nil
+		case keyCtrlC:
+			fmt.Fprint(editor.out, "^C\r\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(s.buf) == 0 {
+				fmt.Fprint(editor.out, "\r\n")
+				return "", io.EOF
+			}
+			s.buf = deleteAt(s.buf, s.pos)
+		case keyDelete:
+			s.buf = deleteAt(s.buf, s.pos)
+		case keyBackspace, keyCtrlH:
+			if s.pos > 0 {
+				s.pos--
+				s.buf = deleteAt(s.buf, s.pos)
+			}
+		case keyCtrlA, keyHome:
+			s.pos = 0
+		case keyCtrlE, keyEnd:
+			s.pos = len(s.buf)
+		case keyCtrlB, keyLeft:
+			s.pos = max(s.pos-1, 0)
+		case keyCtrlF, keyRight:
+			s.pos = min(s.pos+1, len(s.buf))
+		case keyWordLeft:
+			s.pos = wordStart(s.buf, s.pos)
+		case keyWordRight:
+			for s.pos < len(s.buf) && s.buf[s.pos] == ' ' {
+				s.pos++
+			}
+			for s.pos < len(s.buf) && s.buf[s.pos] != ' ' {
+				s.pos++
+			}
+		case keyCtrlK:
+			s.buf = s.buf[:s.pos]
+		case keyCtrlU:
+			s.buf = slices.Delete(s.buf, 0, s.pos)
+			s.pos = 0
+		case keyCtrlW:
+			start := wordStart(s.buf, s.pos)
+			s.buf = slices.Delete(s.buf, start, s.pos)
+			s.pos = start
+		case keyCtrlL:
+			fmt.Fprint(editor.out, "\x1b[H\x1b[2J")
+		case keyCtrlP, keyUp:
+			editor.browse(s, -1)
+		case keyCtrlN, keyDown:
+			editor.browse(s, 1)
+		case keyCtrlR:
+			submit, err := editor.search(s)
+			if err != nil {
+				return "", err
+			}
+			if submit {
+				fmt.Fprint(editor.out, "\r\n")
+				return string(s.buf), nil
+			}
+		case keyTab:
+			tabbed = editor.complete(s)
+		default:
+			if unicode.IsPrint(key) {
+				s.buf = slices.Insert(s.buf, s.pos, key)
+				s.pos++
+			}
+		}
+		s.tabbed = tabbed
+		editor.refresh(s)
+	}
+	fmt.Fprint(editor.out, "\r\n")
+	return string(s.buf), nil
+}
+
+// readKey reads a key, decoding the escape sequences of the special keys.
+func (editor *Editor) readKey() (rune, error) {
+	r, _, err := editor.reader.ReadRune()
+	if err != nil || r != keyEscape {
+		return r, err
+	}
+	r, _, err = editor.reader.ReadRune()
+	if err != nil {
+		return keyUnknown, err
+	}
+	switch r {
+	case 'b':
+		return keyWordLeft, nil
+	case 'f':
+		return keyWordRight, nil
+	case '[', 'O':
+	default:
+		return keyUnknown, nil
+	}
+
+	// CSI and SS3 sequences: parameters followed by a final byte.
+	var params strings.Builder
+	for {
+		r, _, err = editor.reader.ReadRune()
+		if err != nil {
+			return keyUnknown, err
+		}
+		if r >= 0x40 && r <= 0x7e {
+			break
+		}
+		params.WriteRune(r)
+	}
+	switch r {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		if strings.HasSuffix(params.String(), ";5") {
+			return keyWordRight, nil
+		}
+		return keyRight, nil
+	case 'D':
+		if strings.HasSuffix(params.String(), ";5") {
+			return keyWordLeft, nil
+		}
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	case '~':
+		switch params.String() {
+		case "1", "7":
+			return keyHome, nil
+		case "4", "8":
+			return keyEnd, nil
+		case "3":
+			return keyDelete, nil
+		}
+	}
+	return keyUnknown, nil
+}
+
+// refresh redraws the prompt and line and places the cursor.
+func (editor *Editor) refresh(s *state) {
+	var b strings.Builder
+	b.WriteString("\r" + s.prompt + string(s.buf) + "\x1b[K")
+	if back := len(s.buf) - s.pos; back > 0 {
+		fmt.Fprintf(&b, "\x1b[%dD", back)
+	}
+	fmt.Fprint(editor.out, b.String())
+}
+
+// browse moves through the history by step.
+func (editor *Editor) browse(s *state, step int) {
+	index := s.index + step
+	if index < 0 || index >= len(s.history) {
+		return
+	}
+	s.history[s.index] = string(s.buf)
+	s.index = index
+	s.set(s.history[index])
+}
+
+// search runs the Ctrl-R reverse search of the history. It reports whether
+// the found line is submitted with Enter. Other keys keep the found line for
+// editing and are then handled as usual, Ctrl-G restores the line.
+func (editor *Editor) search(s *state) (bool, error) {
+	original := string(s.buf)
+	var query []rune
+	match := len(s.history) - 1
+	found := ""
+	failed := false
+
+	// find looks for query from the entry at from backwards, leaving the
+	// last match in place when nothing matches.
+	find := func(from int) {
+		for i := from; i >= 0; i-- {
+			if i < len(s.history)-1 && strings.Contains(s.history[i], string(query)) {
+				match, found, failed = i, s.history[i], false
+				return
+			}
+		}
+		failed = true
+	}
+
+	for {
+		label := "reverse-i-search"
+		if failed {
+			label = "failing " + label
+		}
+		fmt.Fprintf(editor.out, "\r(%s)`%s': %s\x1b[K", label, string(query), found)
+
+		key, err := editor.readKey()
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case key == keyCtrlR:
+			if len(query) > 0 {
+				find(match - 1)
+			}
+		case key == keyBackspace || key == keyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				find(len(s.history) - 1)
+			}
+		case key == keyCtrlG || key == keyCtrlC:
+			s.set(original)
+			return false, nil
+		case key == keyEnter || key == keyNewline:
+			if found != "" {
+				s.set(found)
+			}
+			return true, nil
+		case key < keyUp && unicode.IsPrint(key):
+			query = append(query, key)
+			find(match)
+		default:
+			if found != "" {
+				s.set(found)
+				s.index = match
+			}
+			if key != keyUnknown {
+				editor.unreadKey(key)
+			}
+			return false, nil
+		}
+	}
+}
+
+// unreadKey makes key the next key read, special keys are pushed back as
+// their escape sequence.
+func (editor *Editor) unreadKey(key rune) {
+	if key > unicode.MaxRune {
+		switch key {
+		case keyWordLeft:
+			editor.pushBack("\x1bb")
+		case keyWordRight:
+			editor.pushBack("\x1bf")
+		case keyLeft:
+			editor.pushBack("\x1b[D")
+		case keyRight:
+			editor.pushBack("\x1b[C")
+		case keyUp:
+			editor.pushBack("\x1b[A")
+		case keyDown:
+			editor.pushBack("\x1b[B")
+		case keyHome:
+			editor.pushBack("\x1b[H")
+		case keyEnd:
+			editor.pushBack("\x1b[F")
+		case keyDelete:
+			editor.pushBack("\x1b[3~")
+		}
+		return
+	}
+	editor.pushBack(string(key))
+}
+
+// pushBack places s in front of the unread input.
+func (editor *Editor) pushBack(s string) {
+	editor.reader = bufio.NewReader(io.MultiReader(strings.NewReader(s), editor.reader))
+}
+
+// complete completes the word before the cursor. It reports whether nothing
+// could be completed, so the next Tab lists the candidates.
+func (editor *Editor) complete(s *state) bool {
+	if editor.Complete == nil {
+		return false
+	}
+	start := s.pos
+	for start > 0 && s.buf[start-1] != ' ' {
+		start--
+	}
+	word := string(s.buf[start:s.pos])
+	var candidates []string
+	for _, candidate := range editor.Complete(string(s.buf[:s.pos])) {
+		if strings.HasPrefix(candidate, word) && !slices.Contains(candidates, candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	if len(candidates) == 0 {
+		fmt.Fprint(editor.out, "\a")
+		return false
+	}
+
+	replacement := commonPrefix(candidates)
+	if len(candidates) == 1 && !strings.HasSuffix(replacement, "=") && !strings.HasSuffix(replacement, "/") {
+		replacement += " "
+	}
+	if replacement != word {
+		s.buf = slices.Concat(s.buf[:start], []rune(replacement), s.buf[s.pos:])
+		s.pos = start + len([]rune(replacement))
+		return false
+	}
+	if s.tabbed {
+		fmt.Fprint(editor.out, "\r\n"+strings.Join(candidates, "  ")+"\r\n")
+		return false
+	}
+	fmt.Fprint(editor.out, "\a")
+	return true
+}
+
+// wordStart returns the start of the word before pos, skipping the spaces
+// right before it.
+func wordStart(buf []rune, pos int) int {
+	for pos > 0 && buf[pos-1] == ' ' {
+		pos--
+	}
+	for pos > 0 && buf[pos-1] != ' ' {
+		pos--
+	}
+	return pos
+}
+
+func deleteAt(buf []rune, pos int) []rune {
+	if pos >= len(buf) {
+		return buf
+	}
+	return slices.Delete(buf, pos, pos+1)
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,127 @@
+package lineedit
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// editLine feeds keys to the editor and returns the line it reads.
+func editLine(t *testing.T, editor *Editor, keys string) (string, string) {
+	t.Helper()
+	var out bytes.Buffer
+	editor.in = strings.NewReader(keys)
+	editor.reader = bufio.NewReader(editor.in)
+	editor.out = &out
+	line, err := editor.edit("> ")
+	if err != nil {
+		t.Fatalf("Unexpected error %v for keys %q", err, keys)
+	}
+	return line, out.String()
+}
+
+func TestEditKeys(t *testing.T) {
+	tests := []struct {
+		keys string
+		want string
+	}{
+		{"hello\r", "hello"},
+		{"helo\x1b[Dl\r", "hello"},
+		{"world\x01hello \r", "hello world"},
+		{"hello world\x17\x17bye\r", "bye"},
+		{"hello world\x01\x1b[3~\x1b[3~\x05!\r", "llo world!"},
+		{"hello world\x1bb\x0b\r", "hello "},
+		{"hello world\x1bb\x15\r", "world"},
+		{"abc\x7f\x7fx\n", "ax"},
+		{"ab\x1b[Hx\x1b[Fy\r", "xaby"},
+		{"ab\x1b[1;5Dx\r", "xab"},
+		{"héllo\x02\x02\x02\x02\x04\r", "hllo"},
+		{"partial", "partial"},
+	}
+	for _, test := range tests {
+		line, _ := editLine(t, New(nil, nil), test.keys)
+		if line != test.want {
+			t.Errorf("Expected %q for keys %q, got %q", test.want, test.keys, line)
+		}
+	}
+}
+
+func TestEditEndAndInterrupt(t *testing.T) {
+	editor := New(strings.NewReader("\x04"), io.Discard)
+	if _, err := editor.edit("> "); err != io.EOF {
+		t.Errorf("Expected io.EOF on Ctrl-D, got %v", err)
+	}
+	editor = New(strings.NewReader(""), io.Discard)
+	if _, err := editor.edit("> "); err != io.EOF {
+		t.Errorf("Expected io.EOF at the end of the input, got %v", err)
+	}
+	editor = New(strings.NewReader("abc\x03"), io.Discard)
+	if _, err := editor.edit("> "); !errors.Is(err, ErrInterrupted) {
+		t.Errorf("Expected ErrInterrupted on Ctrl-C, got %v", err)
+	}
+}
+
+func TestEditHistory(t *testing.T) {
+	history, _ := LoadHistory("", 10)
+	for _, line := range []string{"list", "add milk", "delete 3"} {
+		_ = history.Add(line)
+	}
+	editor := New(nil, nil)
+	editor.History = history
+
+	tests := []struct {
+		keys string
+		want string
+	}{
+		{"\x1b[A\r", "delete 3"},
+		{"\x1b[A\x1b[A\x1b[A\x1b[A\r", "list"},
+		{"new\x1b[A\x1b[B\r", "new"},
+		{"\x10\x10 eggs\r", "add milk eggs"},
+		{"\x12mil\r", "add milk"},
+		{"\x12e\x12\r", "delete 3"},
+		{"\x12li\x1b[C x\r", "list x"},
+		{"typed\x12zzz\x07\r", "typed"},
+	}
+	for _, test := range tests {
+		line, _ := editLine(t, editor, test.keys)
+		if line != test.want {
+			t.Errorf("Expected %q for keys %q, got %q", test.want, test.keys, line)
+		}
+	}
+}
+
+func TestEditComplete(t *testing.T) {
+	editor := New(nil, nil)
+	editor.Complete = func(before string) []string {
+		if !strings.Contains(before, " ") {
+			return []string{"list", "done", "delete"}
+		}
+		return []string{"status=", "sort="}
+	}
+
+	tests := []struct {
+		keys string
+		want string
+	}{
+		{"li\t\r", "list "},
+		{"de\t\r", "delete "},
+		{"d\t\r", "d"},
+		{"list st\t\r", "list status="},
+		{"list s\tta\t\r", "list status="},
+		{"x\t\r", "x"},
+	}
+	for _, test := range tests {
+		line, _ := editLine(t, editor, test.keys)
+		if line != test.want {
+			t.Errorf("Expected %q for keys %q, got %q", test.want, test.keys, line)
+		}
+	}
+
+	_, out := editLine(t, editor, "d\t\t\r")
+	if !strings.Contains(out, "\r\ndone  delete\r\n") {
+		t.Errorf("Expected a second Tab to list the candidates, got %q", out)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build linux
+
+package lineedit
+
+import "syscall"
+
+// The ioctl requests reading and setting the terminal mode.
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package lineedit
+
+import (
+	"errors"
+	"os"
+)
+
+// isTerminal reports false, as lines cannot be edited in the terminal here.
+// Input is then read as from a pipe.
+func isTerminal(file *os.File) bool {
+	return false
+}
+
+// makeRaw is not supported on this platform.
+func makeRaw(fd uintptr) (func() error, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
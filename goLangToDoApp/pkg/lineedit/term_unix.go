@@ -0,0 +1,53 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package lineedit
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func getTermios(fd uintptr) (*syscall.Termios, error) {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&termios)))
+	if errno != 0 {
+		return nil, errno
+	}
+	return &termios, nil
+}
+
+func setTermios(fd uintptr, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// isTerminal reports whether file is a terminal.
+func isTerminal(file *os.File) bool {
+	_, err := getTermios(file.Fd())
+	return err == nil
+}
+
+// makeRaw puts the terminal fd in raw mode, delivering every key as typed
+// without echo or signals, and returns the function restoring its mode.
+// Output processing is kept so newlines still return the carriage.
+func makeRaw(fd uintptr) (func() error, error) {
+	old, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	raw := *old
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	err = setTermios(fd, &raw)
+	if err != nil {
+		return nil, err
+	}
+	return func() error { return setTermios(fd, old) }, nil
+}
@@ -0,0 +1,410 @@
+// Package output writes To-Do items in the formats offered by the command line
+// tools: an aligned table for people, and JSON, JSON lines, CSV, YAML or a Go
+// template for scripts.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// Formats of a Printer.
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatJSONL    = "jsonl"
+	FormatCSV      = "csv"
+	FormatYAML     = "yaml"
+	FormatTemplate = "template"
+)
+
+// Formats lists every format, FormatTable first as the default.
+var Formats = []string{FormatTable, FormatJSON, FormatJSONL, FormatCSV, FormatYAML, FormatTemplate}
+
+// Columns lists every column, named like the JSON fields of an item. ref is
+// the short id.
+var Columns = []string{"id", "ref", "uuid", "status", "description", "priority", "due", "tags",
+	"created_at", "updated_at", "completed_at"}
+
+// DefaultColumns are the columns of the table and CSV formats when none are
+// selected. JSON and YAML print the whole item instead.
+var DefaultColumns = []string{"id", "ref", "status", "priority", "due", "tags", "description"}
+
+// Options select the output of a Printer.
+type Options struct {
+	// Format is one of Formats, defaulting to FormatTable.
+	Format string
+	// Columns selects and orders the printed fields, ignored by the template
+	// format.
+	Columns []string
+	// Template is the Go template of the template format, executed for every
+	// item with the core.Item as data. A newline is added unless it ends with
+	// one.
+	Template string
+}
+
+// Printer writes items in the format of its Options.
+type Printer struct {
+	options  Options
+	template *template.Template
+}
+
+// TemplateFuncs are the functions available to templates besides the
+// builtin ones.
+var TemplateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"date": func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(core.DateLayout)
+	},
+	"json": func(v any) (string, error) {
+		byteValue, err := json.Marshal(v)
+		return string(byteValue), err
+	},
+}
+
+// ParseColumns splits a comma separated list of columns and checks them. An
+// empty list returns nil, which selects the default columns.
+func ParseColumns(s string) ([]string, error) {
+	var columns []string
+	for _, column := range strings.Split(s, ",") {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+		columns = append(columns, column)
+	}
+	err := checkColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+func checkColumns(columns []string) error {
+	for _, column := range columns {
+		if !slices.Contains(Columns, column) {
+			return fmt.Errorf("%w: unknown column %q, expected one of %s",
+				core.ErrValidation, column, strings.Join(Columns, ", "))
+		}
+	}
+	return nil
+}
+
+// New checks options and returns their Printer.
+func New(options Options) (*Printer, error) {
+	if options.Format == "" {
+		options.Format = FormatTable
+	}
+	if !slices.Contains(Formats, options.Format) {
+		return nil, fmt.Errorf("%w: unknown output format %q, expected one of %s",
+			core.ErrValidation, options.Format, strings.Join(Formats, ", "))
+	}
+	err := checkColumns(options.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	printer := &Printer{options: options}
+	if options.Format != FormatTemplate {
+		if options.Template != "" {
+			return nil, fmt.Errorf("%w: a template needs the %s output format", core.ErrValidation, FormatTemplate)
+		}
+		return printer, nil
+	}
+	if options.Template == "" {
+		return nil, fmt.Errorf("%w: the %s output format needs a template", core.ErrValidation, FormatTemplate)
+	}
+	text := options.Template
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	printer.template, err = template.New("item").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid template: %w", core.ErrValidation, err)
+	}
+	return printer, nil
+}
+
+// Format returns the format of the printer.
+func (printer *Printer) Format() string {
+	return printer.options.Format
+}
+
+// Print writes items to w.
+func (printer *Printer) Print(w io.Writer, items []core.Item) error {
+	switch printer.options.Format {
+	case FormatJSON:
+		return printer.printJSON(w, items)
+	case FormatJSONL:
+		return printer.printJSONL(w, items)
+	case FormatCSV:
+		return printer.printCSV(w, items)
+	case FormatYAML:
+		return printer.printYAML(w, items)
+	case FormatTemplate:
+		for _, item := range items {
+			err := printer.template.Execute(w, item)
+			if err != nil {
+				return fmt.Errorf("error executing template: %w", err)
+			}
+		}
+		return nil
+	default:
+		return printer.printTable(w, items)
+	}
+}
+
+// columns returns the selected columns, or fallback when none are.
+func (printer *Printer) columns(fallback []string) []string {
+	if len(printer.options.Columns) > 0 {
+		return printer.options.Columns
+	}
+	return fallback
+}
+
+func (printer *Printer) printTable(w io.Writer, items []core.Item) error {
+	columns := printer.columns(DefaultColumns)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, item := range items {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = cell(item, column)
+			if cells[i] == "" {
+				cells[i] = "-"
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func (printer *Printer) printCSV(w io.Writer, items []core.Item) error {
+	columns := printer.columns(DefaultColumns)
+	cw := csv.NewWriter(w)
+	err := cw.Write(columns)
+	for _, item := range items {
+		if err != nil {
+			break
+		}
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = cell(item, column)
+		}
+		err = cw.Write(record)
+	}
+	cw.Flush()
+	return errors.Join(err, cw.Error())
+}
+
+func (printer *Printer) printJSON(w io.Writer, items []core.Item) error {
+	records := make([]json.Marshaler, len(items))
+	for i, item := range items {
+		records[i] = printer.record(item)
+	}
+	byteValue, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling To-Do Items: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", byteValue)
+	return err
+}
+
+func (printer *Printer) printJSONL(w io.Writer, items []core.Item) error {
+	for _, item := range items {
+		byteValue, err := json.Marshal(printer.record(item))
+		if err != nil {
+			return fmt.Errorf("error marshalling To-Do Item %d: %w", item.ItemId, err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", byteValue)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (printer *Printer) printYAML(w io.Writer, items []core.Item) error {
+	if len(items) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+	columns := printer.columns(slices.DeleteFunc(slices.Clone(Columns), func(column string) bool {
+		return column == "ref"
+	}))
+	var b strings.Builder
+	for _, item := range items {
+		indent := "- "
+		for _, column := range columns {
+			v := value(item, column)
+			if omitted(column, v) {
+				continue
+			}
+			b.WriteString(indent + column + ":")
+			indent = "  "
+			switch v := v.(type) {
+			case []string:
+				b.WriteString("\n")
+				for _, s := range v {
+					b.WriteString("    - " + yamlScalar(s) + "\n")
+				}
+			case int:
+				b.WriteString(" " + strconv.Itoa(v) + "\n")
+			case time.Time:
+				// The same timestamp as in JSON.
+				b.WriteString(" " + v.Format(time.RFC3339Nano) + "\n")
+			case string:
+				b.WriteString(" " + yamlScalar(v) + "\n")
+			}
+		}
+		if indent == "- " {
+			// Every selected field of the item is unset.
+			b.WriteString("- {}\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// record returns the JSON encoding of item: the item itself when no columns
+// are selected, otherwise an object of the selected columns in their order.
+func (printer *Printer) record(item core.Item) json.Marshaler {
+	return jsonRecord{item: item, columns: printer.options.Columns}
+}
+
+type jsonRecord struct {
+	item    core.Item
+	columns []string
+}
+
+func (record jsonRecord) MarshalJSON() ([]byte, error) {
+	if len(record.columns) == 0 {
+		return json.Marshal(record.item)
+	}
+	var b strings.Builder
+	b.WriteString("{")
+	for i, column := range record.columns {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		byteValue, err := json.Marshal(value(record.item, column))
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(strconv.Quote(column) + ":")
+		b.Write(byteValue)
+	}
+	b.WriteString("}")
+	return []byte(b.String()), nil
+}
+
+// value returns the field of item in column: an int, a string, a []string,
+// a time.Time or nil for unset optional fields.
+func value(item core.Item, column string) any {
+	switch column {
+	case "id":
+		return item.ItemId
+	case "ref":
+		return item.ShortId()
+	case "uuid":
+		return item.UUID
+	case "status":
+		return item.Status
+	case "description":
+		return item.Description
+	case "priority":
+		return item.Priority
+	case "due":
+		return timeValue(item.Due)
+	case "tags":
+		return slices.Clone(item.Tags)
+	case "created_at":
+		return timeValue(item.CreatedAt)
+	case "updated_at":
+		return timeValue(item.UpdatedAt)
+	case "completed_at":
+		return timeValue(item.CompletedAt)
+	}
+	return nil
+}
+
+// omitted reports whether YAML leaves out column, whose field is v, like JSON
+// leaves out the unset optional fields of an item.
+func omitted(column string, v any) bool {
+	switch column {
+	case "id", "status", "description":
+		return false
+	}
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+func timeValue(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+// cell returns the text of column in a table or CSV record. Due dates only
+// show the date, as they have no time of day.
+func cell(item core.Item, column string) string {
+	switch v := value(item, column).(type) {
+	case int:
+		return strconv.Itoa(v)
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	case time.Time:
+		if column == "due" {
+			return v.Format(core.DateLayout)
+		}
+		return v.Format(time.RFC3339)
+	}
+	return ""
+}
+
+// yamlScalar returns s as a YAML scalar, double quoted unless it is a plain
+// word that YAML reads back as the same string.
+func yamlScalar(s string) string {
+	plain := s != "" && strings.TrimSpace(s) == s
+	for i, r := range s {
+		isLetter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		isOther := r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' || r == '/' || r == ' '
+		if !isLetter && (i == 0 || !isOther) {
+			plain = false
+			break
+		}
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "y", "n":
+		plain = false
+	}
+	if plain {
+		return s
+	}
+	// A JSON string is a valid double quoted YAML scalar.
+	byteValue, _ := json.Marshal(s)
+	return string(byteValue)
+}
@@ -0,0 +1,144 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"goLangToDoApp/pkg/core"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testItems() []core.Item {
+	due := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	return []core.Item{
+		{ItemId: 1, UUID: "0f8fad5b-d9cb-469f-a165-70867728950e", Status: "started", Description: "Buy milk",
+			Priority: "high", Due: &due, Tags: []string{"home", "shop"}, CreatedAt: &created},
+		{ItemId: 2, Status: "not-started", Description: `Say "yes": true`},
+	}
+}
+
+func render(t *testing.T, options Options) string {
+	t.Helper()
+	printer, err := New(options)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	var out bytes.Buffer
+	err = printer.Print(&out, testItems())
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return out.String()
+}
+
+func TestNewValidation(t *testing.T) {
+	tests := []Options{
+		{Format: "xml"},
+		{Columns: []string{"id", "owner"}},
+		{Format: FormatTemplate},
+		{Format: FormatTemplate, Template: "{{.ItemId"},
+		{Format: FormatJSON, Template: "{{.ItemId}}"},
+	}
+	for _, options := range tests {
+		if _, err := New(options); !errors.Is(err, core.ErrValidation) {
+			t.Errorf("Expected ErrValidation for %+v, got %v", options, err)
+		}
+	}
+	if _, err := ParseColumns("id,,owner"); !errors.Is(err, core.ErrValidation) {
+		t.Errorf("Expected ErrValidation for an unknown column, got %v", err)
+	}
+	columns, err := ParseColumns(" id, status ")
+	if err != nil || strings.Join(columns, ",") != "id,status" {
+		t.Errorf("Unexpected columns %q, error %v", columns, err)
+	}
+}
+
+func TestTable(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(render(t, Options{})), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[0], "ID  REF       STATUS") {
+		t.Errorf("Unexpected header %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "2026-10-18") || !strings.Contains(lines[1], "home,shop") {
+		t.Errorf("Unexpected row %q", lines[1])
+	}
+	if !strings.Contains(lines[2], " - ") {
+		t.Errorf("Expected empty cells to show -, got %q", lines[2])
+	}
+
+	out := render(t, Options{Columns: []string{"description", "id"}})
+	if !strings.HasPrefix(out, "DESCRIPTION      ID\nBuy milk         1\n") {
+		t.Errorf("Unexpected selected columns %q", out)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var items []core.Item
+	err := json.Unmarshal([]byte(render(t, Options{Format: FormatJSON})), &items)
+	if err != nil || len(items) != 2 || items[0].Due == nil || items[1].Description != `Say "yes": true` {
+		t.Errorf("Expected the whole items back, got %+v, error %v", items, err)
+	}
+
+	out := render(t, Options{Format: FormatJSONL, Columns: []string{"status", "id", "due"}})
+	want := `{"status":"started","id":1,"due":"2026-10-18T00:00:00Z"}` + "\n" +
+		`{"status":"not-started","id":2,"due":null}` + "\n"
+	if out != want {
+		t.Errorf("Expected %q, got %q", want, out)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	records, err := csv.NewReader(strings.NewReader(render(t, Options{Format: FormatCSV}))).ReadAll()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if strings.Join(records[0], ",") != strings.Join(DefaultColumns, ",") {
+		t.Errorf("Unexpected header %q", records[0])
+	}
+	if records[1][5] != "home,shop" || records[2][6] != `Say "yes": true` || records[2][4] != "" {
+		t.Errorf("Unexpected records %q", records)
+	}
+}
+
+func TestYAML(t *testing.T) {
+	out := render(t, Options{Format: FormatYAML, Columns: []string{"id", "description", "tags", "due"}})
+	want := "- id: 1\n  description: Buy milk\n  tags:\n    - home\n    - shop\n  due: 2026-10-18T00:00:00Z\n" +
+		"- id: 2\n  description: \"Say \\\"yes\\\": true\"\n"
+	if out != want {
+		t.Errorf("Expected %q, got %q", want, out)
+	}
+
+	// Unset optional fields are left out, as in JSON.
+	out = render(t, Options{Format: FormatYAML, Columns: []string{"due", "tags", "id"}})
+	want = "- due: 2026-10-18T00:00:00Z\n  tags:\n    - home\n    - shop\n  id: 1\n- id: 2\n"
+	if out != want {
+		t.Errorf("Expected %q, got %q", want, out)
+	}
+	out = render(t, Options{Format: FormatYAML})
+	if strings.Contains(out, "null") || strings.Contains(out, "[]") || strings.Count(out, "priority:") != 1 {
+		t.Errorf("Expected no unset fields, got %q", out)
+	}
+	out = render(t, Options{Format: FormatYAML, Columns: []string{"priority"}})
+	if out != "- priority: high\n- {}\n" {
+		t.Errorf("Expected an empty mapping for an item without the fields, got %q", out)
+	}
+
+	for s, want := range map[string]string{"home": "home", "yes": `"yes"`, "a: b": `"a: b"`, "12": `"12"`, "": `""`} {
+		if got := yamlScalar(s); got != want {
+			t.Errorf("Expected %s for %q, got %s", want, s, got)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	out := render(t, Options{Format: FormatTemplate, Template: `{{.ItemId}} {{date .Due}} {{join .Tags "|"}}`})
+	if out != "1 2026-10-18 home|shop\n2  \n" {
+		t.Errorf("Unexpected output %q", out)
+	}
+}
@@ -1,20 +1,23 @@
 package todo
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
-	"io/ioutil"
-	"os"
+	"goLangToDoApp/pkg/core"
+	"log/slog"
 	"slices"
+	"time"
 )
 
-var Statuses = []string{"not-started", "started", "completed"}
-
-// NewToDoStore initializes a new ToDoStore
+// NewToDoStore initializes a new ToDoStore backed by a JSON file
 func NewToDoStore(filePath string) (*ToDoStore, error) {
+	return NewToDoStoreWithBackend(core.NewJSONBackend(filePath))
+}
+
+// NewToDoStoreWithBackend initializes a new ToDoStore on the given backend
+func NewToDoStoreWithBackend(backend core.Backend) (*ToDoStore, error) {
 	store := &ToDoStore{
-		filePath: filePath,
+		backend: backend,
 	}
 	err := store.loadAllToDoItems()
 	if err != nil {
@@ -23,80 +26,175 @@ func NewToDoStore(filePath string) (*ToDoStore, error) {
 	return store, nil
 }
 
-func (store *ToDoStore) AddNewToDoItem(desc string) error {
-	id := 1
-	itemNos := len(store.items)
-	if itemNos > 0 {
-		id = store.items[itemNos-1].ItemId + 1
+func (store *ToDoStore) QueryToDoItems(ctx context.Context, query core.Query) (core.Page, error) {
+	items, err := store.GetAllToDoItems(ctx)
+	if err != nil {
+		return core.Page{}, err
 	}
+	return core.RunQuery(items, query)
+}
 
-	store.items = append(store.items, Item{id, Statuses[0], desc})
-	return store.saveAllToDoItems()
+func (store *ToDoStore) AddNewToDoItem(ctx context.Context, desc string) error {
+	_, err := store.AddToDoItem(ctx, core.Item{Description: desc})
+	return err
 }
 
-func (store *ToDoStore) UpdateToDoItem(id int, status string, desc string) error {
-	if status != "" && !slices.Contains(Statuses, status) {
-		return errors.New("status of To-Do Item is invalid")
+func (store *ToDoStore) AddToDoItem(ctx context.Context, fields core.Item) (core.Item, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return core.Item{}, core.ErrClosed
 	}
+	item, err := core.NewItem(store.nextId, fields, time.Now())
+	if err != nil {
+		return core.Item{}, err
+	}
+	data := store.data()
+	data.NextId++
+	data.Items = append(data.Items, item)
+	return item, store.saveAllToDoItems(ctx, data, core.Change{Op: core.OpAdd, Item: item})
+}
+
+func (store *ToDoStore) UpdateToDoItem(ctx context.Context, id int, status string, desc string) error {
+	_, err := store.PatchToDoItem(ctx, id, core.UpdatePatch(status, desc))
+	return err
+}
 
-	for index, item := range store.items {
+func (store *ToDoStore) PatchToDoItem(ctx context.Context, id int, patch core.ItemPatch) (core.Item, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return core.Item{}, core.ErrClosed
+	}
+	data := store.data()
+	for index, item := range data.Items {
 		if item.ItemId == id {
-			if status != "" {
-				store.items[index].Status = status
-			}
-			if desc != "" {
-				store.items[index].Description = desc
+			before := item
+			err := core.ApplyPatch(&data.Items[index], patch, time.Now())
+			if err != nil {
+				return core.Item{}, err
 			}
-			return store.saveAllToDoItems()
+			item = data.Items[index]
+			return item, store.saveAllToDoItems(ctx, data, core.Change{Op: core.OpUpdate, Item: item, Before: &before})
 		}
 	}
-	return errors.New("To-Do Item failed to update")
+	return core.Item{}, core.NotFoundError(id)
 }
 
-func (store *ToDoStore) DeleteToDoItem(id int) error {
-	for index, item := range store.items {
+func (store *ToDoStore) DeleteToDoItem(ctx context.Context, id int) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return core.ErrClosed
+	}
+	data := store.data()
+	for index, item := range data.Items {
 		if item.ItemId == id {
-			store.items = append(store.items[:index], store.items[index+1:]...)
-			return store.saveAllToDoItems()
+			data.Items = append(data.Items[:index], data.Items[index+1:]...)
+			return store.saveAllToDoItems(ctx, data, core.Change{Op: core.OpDelete, Item: item})
 		}
 
 	}
-	return errors.New("To-Do Item failed to deleted")
+	return core.NotFoundError(id)
 }
 
-func (store *ToDoStore) GetAllToDoItems() []Item {
-	return store.items
+func (store *ToDoStore) Undo(ctx context.Context) (core.Revert, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return core.Revert{}, core.ErrClosed
+	}
+	change, revert, err := store.journal.Undo()
+	if err != nil {
+		return core.Revert{}, err
+	}
+	data := store.data()
+	data.Items = change.Apply(data.Items)
+	return revert, store.saveAllToDoItems(ctx, data, change)
 }
 
-func (store *ToDoStore) loadAllToDoItems() error {
-	byteValue, err := ioutil.ReadFile(store.filePath)
+func (store *ToDoStore) Redo(ctx context.Context) (core.Revert, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return core.Revert{}, core.ErrClosed
+	}
+	change, revert, err := store.journal.Redo()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return fmt.Errorf("error reading file %s: %w", store.filePath, err)
+		return core.Revert{}, err
 	}
+	data := store.data()
+	data.Items = change.Apply(data.Items)
+	return revert, store.saveAllToDoItems(ctx, data, change)
+}
 
-	if len(byteValue) > 0 {
-		err := json.Unmarshal(byteValue, &store.items)
-		if err != nil {
-			return fmt.Errorf("error unmarshalling To-Do items: %w", err)
-		}
+func (store *ToDoStore) GetAllToDoItems(ctx context.Context) ([]core.Item, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return nil, core.ErrClosed
 	}
-	return nil
+	return slices.Clone(store.items), nil
 }
 
-func (store *ToDoStore) saveAllToDoItems() error {
-	// Open json file
-	data, err := json.MarshalIndent(store.items, "", "\t")
+func (store *ToDoStore) Subscribe(ctx context.Context) <-chan core.Change {
+	return store.changes.Subscribe(ctx)
+}
+
+func (store *ToDoStore) Close(ctx context.Context) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return nil
+	}
+	store.closed = true
+	store.changes.Close()
+	err := store.backend.Flush(core.Data{NextId: store.nextId, Items: store.items, Journal: store.journal})
 	if err != nil {
-		return fmt.Errorf("%s\n%s", "Error marshalling To-Do Item(s).", err)
+		slog.ErrorContext(ctx, "Failed to flush To-Do Items.", "error", err)
+		return core.StorageError(err)
 	}
+	slog.DebugContext(ctx, "Closed To-Do store.")
+	return nil
+}
 
-	err = ioutil.WriteFile(store.filePath, data, 0644)
+func (store *ToDoStore) loadAllToDoItems() error {
+	data, err := store.backend.Load()
 	if err != nil {
-		return fmt.Errorf("%s %s\n%s", "Error saving to file.", store.filePath, err)
+		return core.StorageError(err)
 	}
+	store.items = data.Items
+	store.nextId = data.NextId
+	store.journal = data.Journal
+	return nil
+}
+
+// data returns a copy of the store's data for a change to work on, so the
+// store is left as it was when the change fails to save.
+func (store *ToDoStore) data() core.Data {
+	return core.Data{NextId: store.nextId, Items: slices.Clone(store.items), Journal: store.journal.Clone()}
+}
 
+// saveAllToDoItems saves data, the store's data with change made, and only
+// then takes it over.
+func (store *ToDoStore) saveAllToDoItems(ctx context.Context, data core.Data, change core.Change) error {
+	data.Journal.Record(change)
+	err := store.backend.Save(data, change)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save To-Do Items.", "op", change.Op, "Id", change.Item.ItemId, "error", err)
+		if errors.Is(err, core.ErrConflict) {
+			// Take in the other writer's changes, so a retry gets a free id.
+			if loadErr := store.loadAllToDoItems(); loadErr != nil {
+				return loadErr
+			}
+			return err
+		}
+		return core.StorageError(err)
+	}
+	store.items = data.Items
+	store.nextId = data.NextId
+	store.journal = data.Journal
+	slog.DebugContext(ctx, "Saved To-Do Items.", "op", change.Op, "Id", change.Item.ItemId)
+	store.changes.Publish(change)
 	return nil
 }
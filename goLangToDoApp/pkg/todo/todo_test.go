@@ -1,19 +1,27 @@
 package todo
 
 import (
+	"context"
+	"errors"
+	"goLangToDoApp/pkg/core"
+	"goLangToDoApp/pkg/core/coretest"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
 const tempFile = "test_ToDoData.json"
 
 func TestToDo(t *testing.T) {
-	store := &ToDoStore{filePath: tempFile}
-	err := store.saveAllToDoItems()
+	err := core.SaveData(tempFile, core.Data{})
 	if err != nil {
 		t.Fatalf("Failed to save to-do items: %v", err)
 	}
+	store, err := NewToDoStore(tempFile)
+	if err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
 
 	testGetAllToDoItems(store, t)
 	testAddNewToDoItem(store, t)
@@ -25,18 +33,77 @@ func TestToDo(t *testing.T) {
 	if err != nil {
 		log.Fatal(err)
 	}
+	_ = os.Remove(tempFile + core.BackupSuffix)
+}
+
+func TestConformance(t *testing.T) {
+	coretest.Run(t, func(filePath string) (core.Store, error) {
+		return NewToDoStore(filePath)
+	})
+}
+
+func TestConformanceLogBackend(t *testing.T) {
+	coretest.Run(t, func(filePath string) (core.Store, error) {
+		return NewToDoStoreWithBackend(core.NewLogBackend(filePath))
+	})
+}
+
+func TestConcurrentAdd(t *testing.T) {
+	t.Parallel()
+	coretest.RunConcurrent(t, func(filePath string) (core.Store, error) {
+		return NewToDoStore(filePath)
+	})
+}
+
+func TestSaveFailure(t *testing.T) {
+	coretest.RunSaveFailure(t, func(backend core.Backend) (core.Store, error) {
+		return NewToDoStoreWithBackend(backend)
+	})
+}
+
+func TestUpdateRejectedTransition(t *testing.T) {
+	ctx := context.Background()
+	err := core.SetWorkflow(core.Workflow{
+		Statuses:    []string{"not-started", "started", "completed"},
+		Transitions: map[string][]string{"not-started": {"started"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to set workflow: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = core.SetWorkflow(core.DefaultWorkflow)
+	})
+
+	store, err := NewToDoStore(filepath.Join(t.TempDir(), "ToDoData.json"))
+	if err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	if err := store.AddNewToDoItem(ctx, "Task"); err != nil {
+		t.Fatalf("Failed to Add New To-Do Item: %v", err)
+	}
+
+	err = store.UpdateToDoItem(ctx, 1, "completed", "")
+	var transitionErr *core.TransitionError
+	if !errors.As(err, &transitionErr) {
+		t.Fatalf("Expected *core.TransitionError, got %v", err)
+	}
+	if err := store.UpdateToDoItem(ctx, 1, "started", ""); err != nil {
+		t.Errorf("Failed to Update To-Do Item: %v", err)
+	}
 }
 
 func testGetAllToDoItems(store *ToDoStore, t *testing.T) {
-	err := store.GetAllToDoItems()
-	if err != nil || len(store.items) != 0 {
+	ctx := context.Background()
+	items, err := store.GetAllToDoItems(ctx)
+	if err != nil || len(items) != 0 {
 		t.Errorf("Failed to Get To-Do Item(s)")
 	}
 }
 
 func testAddNewToDoItem(store *ToDoStore, t *testing.T) {
+	ctx := context.Background()
 	// Test Add New To-Do Item
-	err := store.AddNewToDoItem("Test Description")
+	err := store.AddNewToDoItem(ctx, "Test Description")
 	if err != nil {
 		t.Errorf("Failed to Add New To-Do Item")
 	}
@@ -47,8 +114,9 @@ func testAddNewToDoItem(store *ToDoStore, t *testing.T) {
 }
 
 func testUpdateToDoItemDesc(store *ToDoStore, t *testing.T) {
+	ctx := context.Background()
 	// Test Update To-Do Item Desc
-	err := store.UpdateToDoItem(1, "", "Updated Description")
+	err := store.UpdateToDoItem(ctx, 1, "", "Updated Description")
 	if err != nil {
 		t.Errorf("Failed to Update To-Do Item")
 	}
@@ -59,8 +127,9 @@ func testUpdateToDoItemDesc(store *ToDoStore, t *testing.T) {
 }
 
 func testUpdateToDoItemStatus(store *ToDoStore, t *testing.T) {
+	ctx := context.Background()
 	// Test Update To-Do Status
-	err := store.UpdateToDoItem(1, "completed", "")
+	err := store.UpdateToDoItem(ctx, 1, "completed", "")
 	if err != nil {
 		t.Errorf("Failed to Update To-Do Item")
 	}
@@ -71,8 +140,9 @@ func testUpdateToDoItemStatus(store *ToDoStore, t *testing.T) {
 }
 
 func testDeleteToDoItem(store *ToDoStore, t *testing.T) {
+	ctx := context.Background()
 	// Test Delete To-Do Item
-	err := store.DeleteToDoItem(1)
+	err := store.DeleteToDoItem(ctx, 1)
 	if err != nil {
 		t.Errorf("Failed to Delete To-Do Item")
 	}
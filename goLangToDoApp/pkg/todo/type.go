@@ -1,12 +1,20 @@
 package todo
 
-type Item struct {
-	ItemId      int    `json:"id"`
-	Status      string `json:"status"`
-	Description string `json:"description"`
-}
+import (
+	"goLangToDoApp/pkg/core"
+	"sync"
+)
 
+// ToDoStore keeps the To-Do items in memory and writes them through to the
+// backend. It is safe for concurrent use, its methods take turns on mu.
 type ToDoStore struct {
-	filePath string
-	items    []Item
+	mu      sync.Mutex
+	backend core.Backend
+	items   []core.Item
+	nextId  int
+	journal core.Journal
+	closed  bool
+	changes core.Changes
 }
+
+var _ core.Store = (*ToDoStore)(nil)
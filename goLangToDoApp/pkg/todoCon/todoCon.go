@@ -1,21 +1,25 @@
 package todoCon
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
-	"io/ioutil"
-	"os"
+	"goLangToDoApp/pkg/core"
+	"log/slog"
 	"slices"
+	"time"
 )
 
-var Statuses = []string{"not-started", "started", "completed"}
-
-// NewToDoStore initializes a new ToDoStore
+// NewToDoStore initializes a new ToDoStore backed by a JSON file
 func NewToDoStore(filePath string) (*ToDoStore, error) {
+	return NewToDoStoreWithBackend(core.NewJSONBackend(filePath))
+}
+
+// NewToDoStoreWithBackend initializes a new ToDoStore on the given backend
+func NewToDoStoreWithBackend(backend core.Backend) (*ToDoStore, error) {
 	store := &ToDoStore{
-		filePath: filePath,
+		backend:  backend,
 		requests: make(chan request),
+		done:     make(chan struct{}),
 	}
 	err := store.loadAllToDoItems()
 	if err != nil {
@@ -28,132 +32,249 @@ func NewToDoStore(filePath string) (*ToDoStore, error) {
 }
 
 func (store *ToDoStore) processRequests() {
+	defer close(store.done)
 	for req := range store.requests {
 		switch req.action {
 		case "get":
-			req.resp <- store.get()
+			err := store.get()
+			req.resp <- response{items: slices.Clone(store.items), err: err}
 		case "add":
-			req.resp <- store.add(req.desc)
+			item, err := store.add(req.ctx, req.item)
+			req.resp <- response{item: item, err: err}
 		case "update":
-			req.resp <- store.update(req.id, req.status, req.desc)
+			item, err := store.update(req.ctx, req.id, req.patch)
+			req.resp <- response{item: item, err: err}
 		case "delete":
-			req.resp <- store.delete(req.id)
+			req.resp <- response{err: store.delete(req.ctx, req.id)}
+		case "undo", "redo":
+			revert, err := store.revert(req.ctx, req.action)
+			req.resp <- response{revert: revert, err: err}
+		case "close":
+			req.resp <- response{err: store.close(req.ctx)}
+			return
 		}
 	}
 }
 
+// get reloads the items, publishing the changes other processes made to the
+// data file since it was last read.
 func (store *ToDoStore) get() error {
-	return store.loadAllToDoItems()
+	before := store.items
+	err := store.loadAllToDoItems()
+	if err != nil {
+		return err
+	}
+	for _, change := range core.Diff(before, store.items) {
+		store.changes.Publish(change)
+	}
+	return nil
 }
 
-func (store *ToDoStore) add(desc string) error {
-	id := 1
-	itemNos := len(store.items)
-	if itemNos > 0 {
-		id = store.items[itemNos-1].ItemId + 1
+func (store *ToDoStore) add(ctx context.Context, fields core.Item) (core.Item, error) {
+	item, err := core.NewItem(store.nextId, fields, time.Now())
+	if err != nil {
+		return core.Item{}, err
 	}
-
-	store.items = append(store.items, Item{id, Statuses[0], desc})
-	return store.saveAllToDoItems()
+	data := store.data()
+	data.NextId++
+	data.Items = append(data.Items, item)
+	return item, store.saveAllToDoItems(ctx, data, core.Change{Op: core.OpAdd, Item: item})
 }
 
-func (store *ToDoStore) update(id int, status string, desc string) error {
-	if status != "" && !slices.Contains(Statuses, status) {
-		return errors.New("status of To-Do Item is invalid")
-	}
-	for index, item := range store.items {
+func (store *ToDoStore) update(ctx context.Context, id int, patch core.ItemPatch) (core.Item, error) {
+	data := store.data()
+	for index, item := range data.Items {
 		if item.ItemId == id {
-			if status != "" {
-				store.items[index].Status = status
-			}
-			if desc != "" {
-				store.items[index].Description = desc
+			before := item
+			err := core.ApplyPatch(&data.Items[index], patch, time.Now())
+			if err != nil {
+				return core.Item{}, err
 			}
-			return store.saveAllToDoItems()
+			item = data.Items[index]
+			return item, store.saveAllToDoItems(ctx, data, core.Change{Op: core.OpUpdate, Item: item, Before: &before})
 		}
 	}
-	return errors.New("To-Do Item failed to update")
+	return core.Item{}, core.NotFoundError(id)
 }
 
-func (store *ToDoStore) delete(id int) error {
-	for index, item := range store.items {
+func (store *ToDoStore) delete(ctx context.Context, id int) error {
+	data := store.data()
+	for index, item := range data.Items {
 		if item.ItemId == id {
-			store.items = append(store.items[:index], store.items[index+1:]...)
-			return store.saveAllToDoItems()
+			data.Items = append(data.Items[:index], data.Items[index+1:]...)
+			return store.saveAllToDoItems(ctx, data, core.Change{Op: core.OpDelete, Item: item})
 		}
 	}
-	return errors.New("To-Do Item failed to delete")
+	return core.NotFoundError(id)
+}
+
+// revert undoes or redoes the last change of the journal, which is reloaded
+// first as another process may have changed the data file.
+func (store *ToDoStore) revert(ctx context.Context, action string) (core.Revert, error) {
+	err := store.get()
+	if err != nil {
+		return core.Revert{}, err
+	}
+	journal := store.journal.Undo
+	if action == "redo" {
+		journal = store.journal.Redo
+	}
+	change, revert, err := journal()
+	if err != nil {
+		return core.Revert{}, err
+	}
+	data := store.data()
+	data.Items = change.Apply(data.Items)
+	return revert, store.saveAllToDoItems(ctx, data, change)
 }
 
-func (store *ToDoStore) GetAllToDoItems() ([]Item, error) {
-	resp := make(chan error)
-	store.requests <- request{
+func (store *ToDoStore) GetAllToDoItems(ctx context.Context) ([]core.Item, error) {
+	res := store.call(request{
+		ctx:    ctx,
 		action: "get",
-		resp:   resp,
+	})
+	return res.items, res.err
+}
+
+func (store *ToDoStore) QueryToDoItems(ctx context.Context, query core.Query) (core.Page, error) {
+	items, err := store.GetAllToDoItems(ctx)
+	if err != nil {
+		return core.Page{}, err
 	}
-	return store.items, <-resp
+	return core.RunQuery(items, query)
+}
+
+func (store *ToDoStore) AddNewToDoItem(ctx context.Context, desc string) error {
+	_, err := store.AddToDoItem(ctx, core.Item{Description: desc})
+	return err
 }
 
-func (store *ToDoStore) AddNewToDoItem(desc string) error {
-	resp := make(chan error)
-	store.requests <- request{
+func (store *ToDoStore) AddToDoItem(ctx context.Context, item core.Item) (core.Item, error) {
+	res := store.call(request{
+		ctx:    ctx,
 		action: "add",
-		desc:   desc,
-		resp:   resp,
-	}
-	return <-resp
+		item:   item,
+	})
+	return res.item, res.err
+}
+
+func (store *ToDoStore) UpdateToDoItem(ctx context.Context, id int, status string, desc string) error {
+	_, err := store.PatchToDoItem(ctx, id, core.UpdatePatch(status, desc))
+	return err
 }
 
-func (store *ToDoStore) UpdateToDoItem(id int, status string, desc string) error {
-	resp := make(chan error)
-	store.requests <- request{
+func (store *ToDoStore) PatchToDoItem(ctx context.Context, id int, patch core.ItemPatch) (core.Item, error) {
+	res := store.call(request{
+		ctx:    ctx,
 		action: "update",
 		id:     id,
-		status: status,
-		desc:   desc,
-		resp:   resp,
-	}
-	return <-resp
+		patch:  patch,
+	})
+	return res.item, res.err
 }
 
-func (store *ToDoStore) DeleteToDoItem(id int) error {
-	resp := make(chan error)
-	store.requests <- request{
+func (store *ToDoStore) DeleteToDoItem(ctx context.Context, id int) error {
+	return store.call(request{
+		ctx:    ctx,
 		action: "delete",
 		id:     id,
-		resp:   resp,
-	}
-	return <-resp
+	}).err
 }
 
-func (store *ToDoStore) loadAllToDoItems() error {
-	byteValue, err := ioutil.ReadFile(store.filePath)
+func (store *ToDoStore) Undo(ctx context.Context) (core.Revert, error) {
+	res := store.call(request{
+		ctx:    ctx,
+		action: "undo",
+	})
+	return res.revert, res.err
+}
+
+func (store *ToDoStore) Redo(ctx context.Context) (core.Revert, error) {
+	res := store.call(request{
+		ctx:    ctx,
+		action: "redo",
+	})
+	return res.revert, res.err
+}
+
+func (store *ToDoStore) close(ctx context.Context) error {
+	store.changes.Close()
+	err := store.backend.Flush(core.Data{NextId: store.nextId, Items: store.items, Journal: store.journal})
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return fmt.Errorf("error reading file %s: %w", store.filePath, err)
+		slog.ErrorContext(ctx, "Failed to flush To-Do Items.", "error", err)
+		return core.StorageError(err)
 	}
+	slog.DebugContext(ctx, "Closed To-Do store.")
+	return nil
+}
 
-	if len(byteValue) > 0 {
-		err := json.Unmarshal(byteValue, &store.items)
-		if err != nil {
-			return fmt.Errorf("error unmarshalling To-Do items: %w", err)
-		}
+func (store *ToDoStore) Subscribe(ctx context.Context) <-chan core.Change {
+	return store.changes.Subscribe(ctx)
+}
+
+// Close flushes the store and stops the actor goroutine.
+func (store *ToDoStore) Close(ctx context.Context) error {
+	err := store.call(request{
+		ctx:    ctx,
+		action: "close",
+	}).err
+	if errors.Is(err, core.ErrClosed) {
+		return nil
 	}
-	return nil
+	return err
 }
 
-func (store *ToDoStore) saveAllToDoItems() error {
-	data, err := json.MarshalIndent(store.items, "", "\t")
-	if err != nil {
-		return fmt.Errorf("error marshalling To-Do items: %w", err)
+// call hands req to the actor goroutine and waits for its response. Requests
+// made after Close fail with core.ErrClosed.
+func (store *ToDoStore) call(req request) response {
+	req.resp = make(chan response, 1)
+	select {
+	case store.requests <- req:
+		return <-req.resp
+	case <-store.done:
+		return response{err: core.ErrClosed}
+	case <-req.ctx.Done():
+		return response{err: req.ctx.Err()}
 	}
+}
 
-	err = ioutil.WriteFile(store.filePath, data, 0644)
+func (store *ToDoStore) loadAllToDoItems() error {
+	data, err := store.backend.Load()
 	if err != nil {
-		return fmt.Errorf("%s %s\n%s", "Error saving to file.", store.filePath, err)
+		return core.StorageError(err)
 	}
+	store.items = data.Items
+	store.nextId = data.NextId
+	store.journal = data.Journal
+	return nil
+}
 
+// data returns a copy of the store's data for a change to work on, so the
+// store is left as it was when the change fails to save.
+func (store *ToDoStore) data() core.Data {
+	return core.Data{NextId: store.nextId, Items: slices.Clone(store.items), Journal: store.journal.Clone()}
+}
+
+// saveAllToDoItems saves data, the store's data with change made, and only
+// then takes it over.
+func (store *ToDoStore) saveAllToDoItems(ctx context.Context, data core.Data, change core.Change) error {
+	data.Journal.Record(change)
+	err := store.backend.Save(data, change)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save To-Do Items.", "op", change.Op, "Id", change.Item.ItemId, "error", err)
+		if errors.Is(err, core.ErrConflict) {
+			// Take in the other writer's changes, so a retry gets a free id.
+			if loadErr := store.get(); loadErr != nil {
+				return loadErr
+			}
+			return err
+		}
+		return core.StorageError(err)
+	}
+	store.items = data.Items
+	store.nextId = data.NextId
+	store.journal = data.Journal
+	slog.DebugContext(ctx, "Saved To-Do Items.", "op", change.Op, "Id", change.Item.ItemId)
+	store.changes.Publish(change)
 	return nil
 }
@@ -1,34 +1,65 @@
 package todoCon
 
 import (
+	"context"
+	"goLangToDoApp/pkg/core"
+	"goLangToDoApp/pkg/core/coretest"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 )
 
 const tempFile = "test_ToDoData.json"
 
+func newStore(filePath string) (core.Store, error) {
+	return NewToDoStore(filePath)
+}
+
+func TestConformance(t *testing.T) {
+	coretest.Run(t, newStore)
+}
+
+func TestConformanceLogBackend(t *testing.T) {
+	coretest.Run(t, func(filePath string) (core.Store, error) {
+		return NewToDoStoreWithBackend(core.NewLogBackend(filePath))
+	})
+}
+
+func TestSaveFailure(t *testing.T) {
+	coretest.RunSaveFailure(t, func(backend core.Backend) (core.Store, error) {
+		return NewToDoStoreWithBackend(backend)
+	})
+}
+
+func TestConcurrentAdd(t *testing.T) {
+	t.Parallel()
+	coretest.RunConcurrent(t, newStore)
+}
+
 func TestToDoStore_Parallel(t *testing.T) {
+	ctx := context.Background()
 	store, err := NewToDoStore(tempFile)
 	if err != nil {
 		t.Fatalf("Failed to initialize store: %v", err)
 	}
 
+	// The steps run in order, as updates and deletes need the added items.
 	var wg sync.WaitGroup
 	t.Run("Add Items in Parallel", func(t *testing.T) {
-		t.Parallel()
 		wg.Add(2)
 
 		go func() {
 			defer wg.Done()
-			if err := store.AddNewToDoItem("Task 1"); err != nil {
+			if err := store.AddNewToDoItem(ctx, "Task 1"); err != nil {
 				t.Errorf("Failed to add Task 1: %v", err)
 			}
 		}()
 
 		go func() {
 			defer wg.Done()
-			if err := store.AddNewToDoItem("Task 2"); err != nil {
+			if err := store.AddNewToDoItem(ctx, "Task 2"); err != nil {
 				t.Errorf("Failed to add Task 2: %v", err)
 			}
 		}()
@@ -37,19 +68,18 @@ func TestToDoStore_Parallel(t *testing.T) {
 	})
 
 	t.Run("Update Items in Parallel", func(t *testing.T) {
-		t.Parallel()
 		wg.Add(2)
 
 		go func() {
 			defer wg.Done()
-			if err := store.UpdateToDoItem(1, "started", "Updated Task 1"); err != nil {
+			if err := store.UpdateToDoItem(ctx, 1, "started", "Updated Task 1"); err != nil {
 				t.Errorf("Failed to update Task 1: %v", err)
 			}
 		}()
 
 		go func() {
 			defer wg.Done()
-			if err := store.UpdateToDoItem(2, "completed", "Updated Task 2"); err != nil {
+			if err := store.UpdateToDoItem(ctx, 2, "completed", "Updated Task 2"); err != nil {
 				t.Errorf("Failed to update Task 2: %v", err)
 			}
 		}()
@@ -58,19 +88,18 @@ func TestToDoStore_Parallel(t *testing.T) {
 	})
 
 	t.Run("Delete Items in Parallel", func(t *testing.T) {
-		t.Parallel()
 		wg.Add(2)
 
 		go func() {
 			defer wg.Done()
-			if err := store.DeleteToDoItem(1); err != nil {
+			if err := store.DeleteToDoItem(ctx, 1); err != nil {
 				t.Errorf("Failed to delete Task 1: %v", err)
 			}
 		}()
 
 		go func() {
 			defer wg.Done()
-			if err := store.DeleteToDoItem(2); err != nil {
+			if err := store.DeleteToDoItem(ctx, 2); err != nil {
 				t.Errorf("Failed to delete Task 2: %v", err)
 			}
 		}()
@@ -80,5 +109,40 @@ func TestToDoStore_Parallel(t *testing.T) {
 
 	t.Cleanup(func() {
 		_ = os.Remove(tempFile)
+		_ = os.Remove(tempFile + core.BackupSuffix)
 	})
 }
+
+func TestSubscribeExternalChanges(t *testing.T) {
+	ctx := context.Background()
+	filePath := filepath.Join(t.TempDir(), "ToDoData.json")
+	store, err := NewToDoStore(filePath)
+	if err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	other, err := NewToDoStore(filePath)
+	if err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = store.Close(ctx)
+		_ = other.Close(ctx)
+	})
+
+	changes := store.Subscribe(ctx)
+	if err := other.AddNewToDoItem(ctx, "Added elsewhere"); err != nil {
+		t.Fatalf("Failed to add To-Do Item: %v", err)
+	}
+	if _, err := store.GetAllToDoItems(ctx); err != nil {
+		t.Fatalf("Failed to get To-Do Items: %v", err)
+	}
+
+	select {
+	case change := <-changes:
+		if change.Op != core.OpAdd || change.Item.Description != "Added elsewhere" {
+			t.Errorf("Unexpected change %+v", change)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the reload to report the item added by the other store")
+	}
+}
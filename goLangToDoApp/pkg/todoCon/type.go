@@ -1,22 +1,35 @@
 package todoCon
 
-type Item struct {
-	ItemId      int    `json:"id"`
-	Status      string `json:"status"`
-	Description string `json:"description"`
-}
+import (
+	"context"
+	"goLangToDoApp/pkg/core"
+)
 
 type request struct {
+	ctx    context.Context
 	action string
-	item   Item
 	id     int
-	status string
-	desc   string
-	resp   chan error
+	item   core.Item
+	patch  core.ItemPatch
+	resp   chan response
+}
+
+type response struct {
+	item   core.Item
+	items  []core.Item
+	revert core.Revert
+	err    error
 }
 
 type ToDoStore struct {
-	filePath string
-	items    []Item
+	backend  core.Backend
+	items    []core.Item
+	nextId   int
+	journal  core.Journal
 	requests chan request
+	// done is closed when the actor goroutine has stopped.
+	done    chan struct{}
+	changes core.Changes
 }
+
+var _ core.Store = (*ToDoStore)(nil)
@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrInternalTarget is returned for deliveries to an address of the server's
+// own networks, which anyone registering a hook could otherwise probe.
+var ErrInternalTarget = errors.New("webhook target is an internal address")
+
+// internalPrefixes are the ranges refused besides the loopback, private,
+// link-local, multicast and unspecified addresses: "this network" and the
+// shared address space some clouds serve their metadata from.
+var internalPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// NewClient returns the client delivering webhooks, sending every request
+// within timeout. It refuses to connect to internal addresses, checked on the
+// resolved address of each connection so a host name cannot point around it,
+// and does not follow redirects.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: checkTarget}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would make the connections, so its address is all we could check.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     transport,
+		CheckRedirect: refuseRedirect,
+	}
+}
+
+// checkTarget is the net.Dialer Control function refusing internal addresses.
+func checkTarget(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: unexpected address %q", ErrInternalTarget, address)
+	}
+	addr := addrPort.Addr().Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrInternalTarget, addr)
+	}
+	for _, prefix := range internalPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrInternalTarget, addr)
+		}
+	}
+	return nil
+}
+
+// refuseRedirect fails deliveries answered with a redirect, which could lead
+// to an internal URL the hook was never registered with.
+func refuseRedirect(req *http.Request, _ []*http.Request) error {
+	return fmt.Errorf("webhook redirected to %s, redirects are not followed", req.URL.Redacted())
+}
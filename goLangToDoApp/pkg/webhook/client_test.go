@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckTarget(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:4700::1111]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"10.1.2.3:80", false},
+		{"192.168.0.1:80", false},
+		{"169.254.169.254:80", false},
+		{"[fd00:ec2::254]:80", false},
+		{"[fe80::1]:80", false},
+		{"100.100.100.200:80", false},
+		{"0.0.0.0:80", false},
+	}
+	for _, tc := range tests {
+		err := checkTarget("tcp", tc.address, nil)
+		if tc.allowed && err != nil {
+			t.Errorf("Expected %s to be allowed, got %v", tc.address, err)
+		}
+		if !tc.allowed && !errors.Is(err, ErrInternalTarget) {
+			t.Errorf("Expected ErrInternalTarget for %s, got %v", tc.address, err)
+		}
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.RedirectHandler("/elsewhere", http.StatusFound))
+	t.Cleanup(server.Close)
+
+	if _, err := NewClient(time.Second).Get(server.URL); !errors.Is(err, ErrInternalTarget) {
+		t.Errorf("Expected ErrInternalTarget connecting to loopback, got %v", err)
+	}
+
+	client := server.Client()
+	client.CheckRedirect = NewClient(time.Second).CheckRedirect
+	if _, err := client.Get(server.URL); err == nil {
+		t.Errorf("Expected the redirect to be refused")
+	}
+}
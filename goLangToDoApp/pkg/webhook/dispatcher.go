@@ -0,0 +1,273 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Defaults of a Dispatcher.
+const (
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = time.Second
+	DefaultRefresh     = 2 * time.Second
+	DefaultTimeout     = 10 * time.Second
+)
+
+// OpenFunc returns the store holding the list of user.
+type OpenFunc func(ctx context.Context, user string) (core.Store, error)
+
+// Dispatcher delivers the changes of the users' stores to their hooks.
+type Dispatcher struct {
+	Hooks *Registry
+	Open  OpenFunc
+	// Client sends the deliveries, see NewClient.
+	Client *http.Client
+	// MaxAttempts bounds the attempts of a delivery. Backoff is the wait
+	// before the first retry, doubled for every further retry.
+	MaxAttempts int
+	Backoff     time.Duration
+	// Refresh is how often watched stores are read, which lets stores that
+	// reload their data file report changes made by other processes. Zero or
+	// less disables it.
+	Refresh time.Duration
+
+	mu       sync.Mutex
+	watching map[string]bool
+	// watchCtx ends the subscriptions, deliverCtx the pending deliveries.
+	watchCtx     context.Context
+	stopWatching context.CancelFunc
+	watchers     sync.WaitGroup
+	deliverCtx   context.Context
+	stopDelivery context.CancelFunc
+	deliveries   sync.WaitGroup
+}
+
+// NewDispatcher initializes a Dispatcher with the default settings.
+func NewDispatcher(hooks *Registry, open OpenFunc) *Dispatcher {
+	dispatcher := &Dispatcher{
+		Hooks:       hooks,
+		Open:        open,
+		Client:      NewClient(DefaultTimeout),
+		MaxAttempts: DefaultMaxAttempts,
+		Backoff:     DefaultBackoff,
+		Refresh:     DefaultRefresh,
+		watching:    make(map[string]bool),
+	}
+	dispatcher.watchCtx, dispatcher.stopWatching = context.WithCancel(context.Background())
+	dispatcher.deliverCtx, dispatcher.stopDelivery = context.WithCancel(context.Background())
+	return dispatcher
+}
+
+// Start watches the stores of every user with a hook.
+func (dispatcher *Dispatcher) Start(ctx context.Context) error {
+	users, err := dispatcher.Hooks.Users()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, user := range users {
+		errs = append(errs, dispatcher.Watch(ctx, user))
+	}
+	return errors.Join(errs...)
+}
+
+// Watch starts delivering the changes of user's store. Watching a user again
+// does nothing. ctx is only used to open the store.
+func (dispatcher *Dispatcher) Watch(ctx context.Context, user string) error {
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	if dispatcher.watching[user] || dispatcher.watchCtx.Err() != nil {
+		return nil
+	}
+
+	store, err := dispatcher.Open(ctx, user)
+	if err != nil {
+		return err
+	}
+	items, err := store.GetAllToDoItems(ctx)
+	if err != nil {
+		return err
+	}
+	statuses := make(map[int]string, len(items))
+	for _, item := range items {
+		statuses[item.ItemId] = item.Status
+	}
+
+	dispatcher.watching[user] = true
+	changes := store.Subscribe(dispatcher.watchCtx)
+	dispatcher.watchers.Add(1)
+	go dispatcher.watch(user, store, changes, statuses)
+	return nil
+}
+
+// Close stops watching the stores and waits for pending deliveries until ctx
+// is done, then abandons their retries.
+func (dispatcher *Dispatcher) Close(ctx context.Context) error {
+	dispatcher.stopWatching()
+	dispatcher.watchers.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		dispatcher.deliveries.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		dispatcher.stopDelivery()
+		return nil
+	case <-ctx.Done():
+		dispatcher.stopDelivery()
+		<-done
+		return fmt.Errorf("webhook deliveries abandoned: %w", ctx.Err())
+	}
+}
+
+// watch dispatches the changes of one store. statuses tracks the status of
+// every item, to tell status changes from other updates.
+func (dispatcher *Dispatcher) watch(user string, store core.Store, changes <-chan core.Change, statuses map[int]string) {
+	defer dispatcher.watchers.Done()
+	defer func() {
+		dispatcher.mu.Lock()
+		delete(dispatcher.watching, user)
+		dispatcher.mu.Unlock()
+	}()
+
+	var refresh <-chan time.Time
+	if dispatcher.Refresh > 0 {
+		ticker := time.NewTicker(dispatcher.Refresh)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	for {
+		select {
+		case <-dispatcher.watchCtx.Done():
+			return
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			payload := Payload{User: user, Item: change.Item, OccurredAt: time.Now().UTC()}
+			switch {
+			case change.Op == core.OpAdd:
+				payload.Event = EventCreated
+			case change.Op == core.OpDelete:
+				payload.Event = EventDeleted
+			case statuses[change.Item.ItemId] != change.Item.Status:
+				payload.Event = EventStatusChanged
+				payload.PreviousStatus = statuses[change.Item.ItemId]
+			default:
+				payload.Event = EventUpdated
+			}
+			if change.Op == core.OpDelete {
+				delete(statuses, change.Item.ItemId)
+			} else {
+				statuses[change.Item.ItemId] = change.Item.Status
+			}
+			dispatcher.dispatch(payload)
+		case <-refresh:
+			_, err := store.GetAllToDoItems(dispatcher.watchCtx)
+			if errors.Is(err, core.ErrClosed) {
+				return
+			}
+		}
+	}
+}
+
+// dispatch starts a delivery of payload to every hook of the user wanting it.
+func (dispatcher *Dispatcher) dispatch(payload Payload) {
+	hooks, err := dispatcher.Hooks.Matching(payload.User, payload.Event)
+	if err != nil {
+		slog.Error("Failed to read webhooks.", "error", err)
+		return
+	}
+	for _, hook := range hooks {
+		payload := payload
+		payload.Delivery = uuid.NewString()
+		dispatcher.deliveries.Add(1)
+		go func() {
+			defer dispatcher.deliveries.Done()
+			dispatcher.deliver(hook, payload)
+		}()
+	}
+}
+
+// deliver sends payload to hook until it is accepted with a 2xx response or
+// MaxAttempts is reached, recording every attempt.
+func (dispatcher *Dispatcher) deliver(hook Hook, payload Payload) {
+	ctx := dispatcher.deliverCtx
+	logger := slog.With("webhook", hook.Id, "delivery", payload.Delivery, "event", payload.Event)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to encode webhook payload.", "error", err)
+		return
+	}
+
+	delivery := Delivery{Id: payload.Delivery, HookId: hook.Id, Event: payload.Event, ItemId: payload.Item.ItemId}
+	backoff := dispatcher.Backoff
+	attempts := max(dispatcher.MaxAttempts, 1)
+	for attempt := 1; attempt <= attempts; attempt++ {
+		result := dispatcher.send(ctx, hook, payload, body)
+		delivery.Attempts = append(delivery.Attempts, result)
+		delivery.Delivered = result.Error == ""
+		err := dispatcher.Hooks.Record(delivery)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to record webhook delivery.", "error", err)
+		}
+		if delivery.Delivered {
+			logger.InfoContext(ctx, "Webhook delivered.", "attempt", attempt, "status", result.StatusCode)
+			return
+		}
+		logger.WarnContext(ctx, "Webhook delivery failed.", "attempt", attempt, "status", result.StatusCode, "error", result.Error)
+
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	logger.ErrorContext(ctx, "Webhook delivery given up.", "attempts", len(delivery.Attempts))
+}
+
+// send makes a single delivery attempt.
+func (dispatcher *Dispatcher) send(ctx context.Context, hook Hook, payload Payload, body []byte) Attempt {
+	start := time.Now()
+	attempt := Attempt{At: start.UTC()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "goLangToDoApp-Webhook")
+	req.Header.Set(EventHeader, payload.Event)
+	req.Header.Set(DeliveryHeader, payload.Delivery)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+
+	res, err := dispatcher.Client.Do(req)
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	_ = res.Body.Close()
+	attempt.StatusCode = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected response status %s", res.Status)
+	}
+	return attempt
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"goLangToDoApp/pkg/core"
+	"goLangToDoApp/pkg/todoCon"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver is a webhook endpoint failing the first attempts of every
+// delivery.
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	attempts map[string]int
+	payloads chan Payload
+	secret   string
+	t        *testing.T
+}
+
+func (receiver *receiver) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	if !Verify(receiver.secret, body, req.Header.Get(SignatureHeader)) {
+		receiver.t.Errorf("Invalid signature %q", req.Header.Get(SignatureHeader))
+	}
+
+	receiver.mu.Lock()
+	receiver.attempts[req.Header.Get(DeliveryHeader)]++
+	attempt := receiver.attempts[req.Header.Get(DeliveryHeader)]
+	receiver.mu.Unlock()
+	if attempt <= receiver.failures {
+		res.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		receiver.t.Errorf("Invalid payload: %v", err)
+	}
+	if payload.Event != req.Header.Get(EventHeader) {
+		receiver.t.Errorf("Event header %q does not match payload %q", req.Header.Get(EventHeader), payload.Event)
+	}
+	receiver.payloads <- payload
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+	store, err := todoCon.NewToDoStore(filepath.Join(t.TempDir(), "ToDoData.json"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	target := &receiver{failures: 2, attempts: make(map[string]int), payloads: make(chan Payload, 10), secret: "shh", t: t}
+	server := httptest.NewServer(target)
+	t.Cleanup(server.Close)
+
+	registry := NewRegistry(filepath.Join(t.TempDir(), "webhooks.json"))
+	hook, err := registry.Register("alice", server.URL, []string{EventCreated, EventStatusChanged}, "shh", time.Now())
+	if err != nil {
+		t.Fatalf("Failed to register webhook: %v", err)
+	}
+
+	dispatcher := NewDispatcher(registry, func(context.Context, string) (core.Store, error) { return store, nil })
+	dispatcher.Client = server.Client()
+	dispatcher.Backoff = time.Millisecond
+	dispatcher.Refresh = 0
+	if err := dispatcher.Start(ctx); err != nil {
+		t.Fatalf("Failed to start dispatcher: %v", err)
+	}
+
+	item, err := store.AddToDoItem(ctx, core.Item{Description: "Task 1"})
+	if err != nil {
+		t.Fatalf("Failed to add To-Do Item: %v", err)
+	}
+	if err := store.UpdateToDoItem(ctx, item.ItemId, "", "Renamed"); err != nil {
+		t.Fatalf("Failed to update To-Do Item: %v", err)
+	}
+	if err := store.UpdateToDoItem(ctx, item.ItemId, "started", ""); err != nil {
+		t.Fatalf("Failed to update To-Do Item: %v", err)
+	}
+
+	received := map[string]Payload{}
+	for len(received) < 2 {
+		select {
+		case payload := <-target.payloads:
+			received[payload.Event] = payload
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected created and status_changed deliveries, got %v", received)
+		}
+	}
+	if received[EventCreated].Item.ItemId != item.ItemId || received[EventCreated].User != "alice" {
+		t.Errorf("Unexpected created payload %+v", received[EventCreated])
+	}
+	if changed := received[EventStatusChanged]; changed.PreviousStatus != core.Statuses[0] || changed.Item.Status != "started" {
+		t.Errorf("Unexpected status_changed payload %+v", changed)
+	}
+
+	if err := dispatcher.Close(ctx); err != nil {
+		t.Fatalf("Failed to close dispatcher: %v", err)
+	}
+	deliveries, err := registry.Deliveries("alice", hook.Id)
+	if err != nil || len(deliveries) != 2 {
+		t.Fatalf("Expected 2 recorded deliveries, got %+v (%v)", deliveries, err)
+	}
+	for _, delivery := range deliveries {
+		if !delivery.Delivered || len(delivery.Attempts) != 3 || delivery.Attempts[0].StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Expected 2 failed attempts before success, got %+v", delivery)
+		}
+	}
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	ctx := context.Background()
+	store, err := todoCon.NewToDoStore(filepath.Join(t.TempDir(), "ToDoData.json"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, _ *http.Request) {
+		res.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	registry := NewRegistry(filepath.Join(t.TempDir(), "webhooks.json"))
+	hook, err := registry.Register("", server.URL, nil, "", time.Now())
+	if err != nil {
+		t.Fatalf("Failed to register webhook: %v", err)
+	}
+	dispatcher := NewDispatcher(registry, func(context.Context, string) (core.Store, error) { return store, nil })
+	dispatcher.Client = server.Client()
+	dispatcher.Backoff = time.Millisecond
+	dispatcher.MaxAttempts = 3
+	if err := dispatcher.Watch(ctx, ""); err != nil {
+		t.Fatalf("Failed to watch store: %v", err)
+	}
+
+	if err := store.AddNewToDoItem(ctx, "Task 1"); err != nil {
+		t.Fatalf("Failed to add To-Do Item: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, _ := registry.Deliveries("", hook.Id)
+		if len(deliveries) == 1 && len(deliveries[0].Attempts) == 3 {
+			if deliveries[0].Delivered {
+				t.Errorf("Expected the delivery to fail")
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 3 recorded attempts, got %+v", deliveries)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := dispatcher.Close(ctx); err != nil {
+		t.Errorf("Failed to close dispatcher: %v", err)
+	}
+}
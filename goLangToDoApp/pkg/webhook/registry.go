@@ -0,0 +1,358 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxDeliveries is the number of deliveries kept for each hook, older ones are
+// dropped.
+const MaxDeliveries = 20
+
+// DeliveriesSuffix is appended to the webhook file name to name the delivery
+// log.
+const DeliveriesSuffix = ".deliveries"
+
+// compactSize is the size of the delivery log above which recording a
+// delivery rewrites it with only the deliveries kept. Tests lower it.
+var compactSize int64 = 1 << 20
+
+// registryData is the content of the webhook file.
+type registryData struct {
+	Hooks []Hook `json:"hooks"`
+}
+
+// Registry keeps the hooks in a JSON file, which is reloaded when it changes
+// like the token file. Delivery attempts are appended to a log next to it, a
+// JSON record of the delivery per line, so recording one does not rewrite
+// the hooks.
+type Registry struct {
+	filePath string
+	mu       sync.Mutex
+	data     registryData
+	modTime  time.Time
+	size     int64
+}
+
+// NewRegistry initializes a Registry for filePath.
+func NewRegistry(filePath string) *Registry {
+	return &Registry{filePath: filePath}
+}
+
+// Register adds a hook for user delivering events to target. An empty secret
+// is replaced by a random one. The returned hook includes the secret.
+func (registry *Registry) Register(user string, target string, events []string, secret string, now time.Time) (Hook, error) {
+	hook := Hook{
+		Id:        uuid.NewString(),
+		User:      user,
+		URL:       target,
+		Events:    slices.Compact(slices.Sorted(slices.Values(events))),
+		Secret:    secret,
+		CreatedAt: now.UTC(),
+	}
+	err := hook.validate()
+	if err != nil {
+		return Hook{}, err
+	}
+	if hook.Secret == "" {
+		secretBytes := make([]byte, 32)
+		_, err = rand.Read(secretBytes)
+		if err != nil {
+			return Hook{}, fmt.Errorf("error generating webhook secret: %w", err)
+		}
+		hook.Secret = base64.RawURLEncoding.EncodeToString(secretBytes)
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	err = registry.reload()
+	if err != nil {
+		return Hook{}, err
+	}
+	registry.data.Hooks = append(registry.data.Hooks, hook)
+	return hook, registry.save()
+}
+
+// Remove deletes the hook with id of user and its deliveries.
+func (registry *Registry) Remove(user string, id string) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	err := registry.reload()
+	if err != nil {
+		return err
+	}
+	index := registry.index(user, id)
+	if index < 0 {
+		return fmt.Errorf("webhook %q: %w", id, core.ErrNotFound)
+	}
+	// The deliveries of the hook are dropped when the log is compacted.
+	registry.data.Hooks = slices.Delete(registry.data.Hooks, index, index+1)
+	return registry.save()
+}
+
+// List returns the hooks of user without their secrets.
+func (registry *Registry) List(user string) ([]Hook, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	err := registry.reload()
+	if err != nil {
+		return nil, err
+	}
+	var hooks []Hook
+	for _, hook := range registry.data.Hooks {
+		if hook.User == user {
+			hook.Secret = ""
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+// Users returns every user with at least one hook.
+func (registry *Registry) Users() ([]string, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	err := registry.reload()
+	if err != nil {
+		return nil, err
+	}
+	var users []string
+	for _, hook := range registry.data.Hooks {
+		if !slices.Contains(users, hook.User) {
+			users = append(users, hook.User)
+		}
+	}
+	return users, nil
+}
+
+// Matching returns the hooks of user subscribed to event, with their secrets.
+func (registry *Registry) Matching(user string, event string) ([]Hook, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	err := registry.reload()
+	if err != nil {
+		return nil, err
+	}
+	var hooks []Hook
+	for _, hook := range registry.data.Hooks {
+		if hook.User == user && hook.Wants(event) {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+// Deliveries returns the recorded deliveries of the hook with id of user,
+// most recent first.
+func (registry *Registry) Deliveries(user string, id string) ([]Delivery, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	err := registry.reload()
+	if err != nil {
+		return nil, err
+	}
+	if registry.index(user, id) < 0 {
+		return nil, fmt.Errorf("webhook %q: %w", id, core.ErrNotFound)
+	}
+	logged, err := registry.readDeliveries()
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []Delivery
+	for _, delivery := range slices.Backward(logged) {
+		if delivery.HookId == id {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// Record stores the current state of delivery, replacing its previous
+// record. Deliveries of removed hooks are not recorded.
+func (registry *Registry) Record(delivery Delivery) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	err := registry.reload()
+	if err != nil {
+		return err
+	}
+	if !registry.registered(delivery.HookId) {
+		return nil
+	}
+
+	line, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("error marshalling webhook delivery: %w", err)
+	}
+	logPath := registry.filePath + DeliveriesSuffix
+	err = os.MkdirAll(filepath.Dir(logPath), 0755)
+	if err != nil {
+		return fmt.Errorf("error saving webhook deliveries %s: %w", logPath, err)
+	}
+	file, err := os.OpenFile(logPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("error saving webhook deliveries %s: %w", logPath, err)
+	}
+	size, err := appendLine(file, line)
+	err = errors.Join(err, file.Close())
+	if err != nil {
+		return fmt.Errorf("error saving webhook deliveries %s: %w", logPath, err)
+	}
+	if size > compactSize {
+		return registry.compactDeliveries()
+	}
+	return nil
+}
+
+// appendLine appends line to the log file, after ending a torn last line so
+// the new record stays readable, and returns the new size of the file.
+func appendLine(file *os.File, line []byte) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		_, err = file.ReadAt(last, info.Size()-1)
+		if err != nil {
+			return 0, err
+		}
+		if last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+	line = append(line, '\n')
+	_, err = file.Write(line)
+	return info.Size() + int64(len(line)), err
+}
+
+// readDeliveries returns the last record of every logged delivery, in the
+// order they were last recorded, keeping the most recent MaxDeliveries of
+// every hook. A torn last line, left by a crash while appending, is skipped.
+func (registry *Registry) readDeliveries() ([]Delivery, error) {
+	logPath := registry.filePath + DeliveriesSuffix
+	byteValue, err := os.ReadFile(logPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading webhook deliveries %s: %w", logPath, err)
+	}
+
+	var records []Delivery
+	last := make(map[string]int)
+	for _, line := range bytes.Split(byteValue, []byte("\n")) {
+		var delivery Delivery
+		if len(line) == 0 || json.Unmarshal(line, &delivery) != nil {
+			continue
+		}
+		last[delivery.Id] = len(records)
+		records = append(records, delivery)
+	}
+
+	var deliveries []Delivery
+	kept := make(map[string]int)
+	for i, delivery := range slices.Backward(records) {
+		if last[delivery.Id] != i || kept[delivery.HookId] == MaxDeliveries {
+			continue
+		}
+		kept[delivery.HookId]++
+		deliveries = append(deliveries, delivery)
+	}
+	slices.Reverse(deliveries)
+	return deliveries, nil
+}
+
+// compactDeliveries rewrites the delivery log with only the deliveries kept
+// of the registered hooks.
+func (registry *Registry) compactDeliveries() error {
+	deliveries, err := registry.readDeliveries()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, delivery := range deliveries {
+		if !registry.registered(delivery.HookId) {
+			continue
+		}
+		line, err := json.Marshal(delivery)
+		if err != nil {
+			return fmt.Errorf("error marshalling webhook delivery: %w", err)
+		}
+		buf.Write(append(line, '\n'))
+	}
+	logPath := registry.filePath + DeliveriesSuffix
+	err = core.WriteFileAtomic(logPath, buf.Bytes(), 0600)
+	if err != nil {
+		return fmt.Errorf("error compacting webhook deliveries %s: %w", logPath, err)
+	}
+	return nil
+}
+
+// index returns the position of the hook with id of user, or -1.
+func (registry *Registry) index(user string, id string) int {
+	return slices.IndexFunc(registry.data.Hooks, func(hook Hook) bool {
+		return hook.Id == id && hook.User == user
+	})
+}
+
+// registered reports whether a hook with id exists.
+func (registry *Registry) registered(id string) bool {
+	return slices.ContainsFunc(registry.data.Hooks, func(hook Hook) bool { return hook.Id == id })
+}
+
+// reload reads the webhook file when it changed since the last read.
+func (registry *Registry) reload() error {
+	info, err := os.Stat(registry.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		registry.data, registry.modTime, registry.size = registryData{}, time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading webhook file %s: %w", registry.filePath, err)
+	}
+	if info.ModTime().Equal(registry.modTime) && info.Size() == registry.size {
+		return nil
+	}
+
+	byteValue, err := os.ReadFile(registry.filePath)
+	if err != nil {
+		return fmt.Errorf("error reading webhook file %s: %w", registry.filePath, err)
+	}
+	var data registryData
+	err = json.Unmarshal(byteValue, &data)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling webhook file %s: %w", registry.filePath, err)
+	}
+	registry.data, registry.modTime, registry.size = data, info.ModTime(), info.Size()
+	return nil
+}
+
+func (registry *Registry) save() error {
+	byteValue, err := json.MarshalIndent(registry.data, "", "\t")
+	if err != nil {
+		return fmt.Errorf("error marshalling webhooks: %w", err)
+	}
+	err = os.MkdirAll(filepath.Dir(registry.filePath), 0755)
+	if err == nil {
+		err = core.WriteFileAtomic(registry.filePath, byteValue, 0600)
+	}
+	if err != nil {
+		return fmt.Errorf("error saving webhook file %s: %w", registry.filePath, err)
+	}
+	// Force a reload so the next read sees exactly what was written.
+	registry.modTime = time.Time{}
+	return nil
+}
@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"errors"
+	"goLangToDoApp/pkg/core"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "webhooks.json")
+	registry := NewRegistry(filePath)
+	now := time.Now()
+
+	hook, err := registry.Register("alice", "http://example.com/hook", []string{EventDeleted, EventCreated}, "", now)
+	if err != nil {
+		t.Fatalf("Failed to register webhook: %v", err)
+	}
+	if hook.Secret == "" {
+		t.Errorf("Expected a generated secret")
+	}
+	if _, err := registry.Register("bob", "http://example.com/other", nil, "shh", now); err != nil {
+		t.Fatalf("Failed to register webhook: %v", err)
+	}
+
+	// The file holds the secrets, only the owner may read it or its backup.
+	for _, name := range []string{filePath, filePath + core.BackupSuffix} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", name, err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("Expected %s to be private, got %v", name, info.Mode().Perm())
+		}
+	}
+
+	// A second registry sees the hooks, as a server does for hooks added
+	// by another process.
+	other := NewRegistry(filePath)
+	hooks, err := other.List("alice")
+	if err != nil || len(hooks) != 1 || hooks[0].Id != hook.Id || hooks[0].Secret != "" {
+		t.Errorf("Expected alice's hook without its secret, got %+v (%v)", hooks, err)
+	}
+	matching, err := other.Matching("alice", EventCreated)
+	if err != nil || len(matching) != 1 || matching[0].Secret != hook.Secret {
+		t.Errorf("Expected the matching hook with its secret, got %+v (%v)", matching, err)
+	}
+	if matching, _ := other.Matching("alice", EventUpdated); len(matching) != 0 {
+		t.Errorf("Expected no hook for a filtered event, got %+v", matching)
+	}
+	if users, _ := other.Users(); len(users) != 2 {
+		t.Errorf("Expected 2 users with hooks, got %v", users)
+	}
+
+	// Recording attempts appends to the delivery log, leaving the hooks.
+	before, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatalf("Failed to stat %s: %v", filePath, err)
+	}
+	for i := 0; i < MaxDeliveries+2; i++ {
+		delivery := Delivery{Id: string(rune('a' + i)), HookId: hook.Id, Event: EventCreated, ItemId: i}
+		if err := registry.Record(delivery); err != nil {
+			t.Fatalf("Failed to record delivery: %v", err)
+		}
+	}
+	deliveries, err := registry.Deliveries("alice", hook.Id)
+	if err != nil || len(deliveries) != MaxDeliveries || deliveries[0].ItemId != MaxDeliveries+1 {
+		t.Errorf("Expected the %d most recent deliveries first, got %d (%v)", MaxDeliveries, len(deliveries), err)
+	}
+	if after, err := os.Stat(filePath); err != nil || !after.ModTime().Equal(before.ModTime()) {
+		t.Errorf("Expected the webhook file to stay unchanged by deliveries (%v)", err)
+	}
+	if _, err := registry.Deliveries("bob", hook.Id); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another user's hook, got %v", err)
+	}
+
+	if err := registry.Remove("bob", hook.Id); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound removing another user's hook, got %v", err)
+	}
+	if err := registry.Remove("alice", hook.Id); err != nil {
+		t.Fatalf("Failed to remove webhook: %v", err)
+	}
+	if hooks, _ := registry.List("alice"); len(hooks) != 0 {
+		t.Errorf("Expected no hooks after removal, got %+v", hooks)
+	}
+}
+
+func TestRegistryDeliveryLog(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "webhooks.json")
+	registry := NewRegistry(filePath)
+	now := time.Now()
+	kept, err := registry.Register("alice", "http://example.com/hook", nil, "", now)
+	if err != nil {
+		t.Fatalf("Failed to register webhook: %v", err)
+	}
+	removed, err := registry.Register("alice", "http://example.com/other", nil, "", now)
+	if err != nil {
+		t.Fatalf("Failed to register webhook: %v", err)
+	}
+
+	// Every attempt replaces the record of the delivery.
+	delivery := Delivery{Id: "d1", HookId: kept.Id, Event: EventCreated}
+	for range 3 {
+		delivery.Attempts = append(delivery.Attempts, Attempt{At: now, Error: "refused"})
+		if err := registry.Record(delivery); err != nil {
+			t.Fatalf("Failed to record delivery: %v", err)
+		}
+	}
+	if err := registry.Record(Delivery{Id: "d2", HookId: removed.Id, Event: EventCreated}); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+	deliveries, err := registry.Deliveries("alice", kept.Id)
+	if err != nil || len(deliveries) != 1 || len(deliveries[0].Attempts) != 3 {
+		t.Fatalf("Expected one delivery with 3 attempts, got %+v (%v)", deliveries, err)
+	}
+
+	// A torn last line is skipped.
+	logPath := filePath + DeliveriesSuffix
+	file, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatalf("Failed to open delivery log: %v", err)
+	}
+	_, _ = file.WriteString(`{"id":"d3","hook_`)
+	_ = file.Close()
+	if deliveries, err := registry.Deliveries("alice", kept.Id); err != nil || len(deliveries) != 1 {
+		t.Errorf("Expected the torn record to be skipped, got %+v (%v)", deliveries, err)
+	}
+
+	// Compacting keeps the last record of the deliveries of registered hooks.
+	if err := registry.Remove("alice", removed.Id); err != nil {
+		t.Fatalf("Failed to remove webhook: %v", err)
+	}
+	saved := compactSize
+	compactSize = 0
+	t.Cleanup(func() { compactSize = saved })
+	delivery.Delivered = true
+	if err := registry.Record(delivery); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read delivery log: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 || !strings.Contains(string(data), `"delivered":true`) {
+		t.Errorf("Expected the compacted log to hold the last record only, got %s", data)
+	}
+}
@@ -0,0 +1,107 @@
+// Package webhook notifies registered URLs of changes to To-Do items. Every
+// delivery is a signed JSON payload, retried with backoff until the receiver
+// accepts it, and its attempts are recorded with the hooks.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"goLangToDoApp/pkg/core"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Events a hook can subscribe to.
+const (
+	EventCreated       = "created"
+	EventUpdated       = "updated"
+	EventStatusChanged = "status_changed"
+	EventDeleted       = "deleted"
+)
+
+// Events lists every event, in the order they are documented.
+var Events = []string{EventCreated, EventUpdated, EventStatusChanged, EventDeleted}
+
+// Headers of a delivery request.
+const (
+	EventHeader     = "X-Todo-Event"
+	DeliveryHeader  = "X-Todo-Delivery"
+	SignatureHeader = "X-Todo-Signature"
+)
+
+// Hook is a registered webhook.
+type Hook struct {
+	Id   string `json:"id"`
+	User string `json:"user"`
+	URL  string `json:"url"`
+	// Events filters the delivered events, empty delivers every event.
+	Events []string `json:"events,omitempty"`
+	// Secret signs the payloads. It is only shown when the hook is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Wants reports whether the hook subscribed to event.
+func (hook Hook) Wants(event string) bool {
+	return len(hook.Events) == 0 || slices.Contains(hook.Events, event)
+}
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	Delivery string    `json:"delivery"`
+	Event    string    `json:"event"`
+	User     string    `json:"user"`
+	Item     core.Item `json:"item"`
+	// PreviousStatus is set for status_changed events.
+	PreviousStatus string    `json:"previous_status,omitempty"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+// Delivery records the attempts to deliver one payload to a hook.
+type Delivery struct {
+	Id        string    `json:"id"`
+	HookId    string    `json:"hook_id"`
+	Event     string    `json:"event"`
+	ItemId    int       `json:"item_id"`
+	Delivered bool      `json:"delivered"`
+	Attempts  []Attempt `json:"attempts"`
+}
+
+// Attempt is a single try of a delivery.
+type Attempt struct {
+	At time.Time `json:"at"`
+	// StatusCode is the response status, zero when no response was received.
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// Sign returns the signature header value of body: "sha256=" followed by the
+// hex encoded HMAC-SHA256 of body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body, for receivers.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// validate checks the URL and events of a hook being registered.
+func (hook Hook) validate() error {
+	target, err := url.Parse(hook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: webhook url %q must be an absolute http or https URL", core.ErrValidation, hook.URL)
+	}
+	for _, event := range hook.Events {
+		if !slices.Contains(Events, event) {
+			return fmt.Errorf("%w: unknown webhook event %q, expected one of %v", core.ErrValidation, event, Events)
+		}
+	}
+	return nil
+}
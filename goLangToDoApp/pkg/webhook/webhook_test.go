@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"errors"
+	"goLangToDoApp/pkg/core"
+	"strings"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"created"}`)
+	signature := Sign("secret", body)
+	if !strings.HasPrefix(signature, "sha256=") || len(signature) != len("sha256=")+64 {
+		t.Errorf("Unexpected signature format %q", signature)
+	}
+	if !Verify("secret", body, signature) {
+		t.Errorf("Expected the signature to verify")
+	}
+	if Verify("other", body, signature) || Verify("secret", []byte(`{}`), signature) {
+		t.Errorf("Expected the signature to fail with another secret or body")
+	}
+}
+
+func TestHookWants(t *testing.T) {
+	all := Hook{}
+	if !all.Wants(EventDeleted) {
+		t.Errorf("Expected a hook without filters to want every event")
+	}
+	filtered := Hook{Events: []string{EventCreated}}
+	if !filtered.Wants(EventCreated) || filtered.Wants(EventDeleted) {
+		t.Errorf("Expected the filter to apply")
+	}
+}
+
+func TestHookValidate(t *testing.T) {
+	tests := []Hook{
+		{URL: "ftp://example.com/hook"},
+		{URL: "/relative"},
+		{URL: "http://example.com/hook", Events: []string{"renamed"}},
+	}
+	for _, hook := range tests {
+		if err := hook.validate(); !errors.Is(err, core.ErrValidation) {
+			t.Errorf("Expected ErrValidation for %+v, got %v", hook, err)
+		}
+	}
+	if err := (Hook{URL: "https://example.com/hook", Events: Events}).validate(); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}